@@ -0,0 +1,492 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/spf13/cobra"
+	"github.com/streadway/amqp"
+)
+
+// benchTimestampSize is the number of bytes at the beginning of each message body
+// that hold the publishing time, which is used for measuring the latency.
+const benchTimestampSize = 8
+
+// benchHeader is the header identifying the messages published by a benchmark run.
+// Its value is the ID of the run, so that consumers only acknowledge and count the
+// run's messages and requeue other messages published to an existing queue.
+const benchHeader = "x-buneary-bench"
+
+// benchPercentiles are the latency percentiles printed by `buneary bench`.
+var benchPercentiles = []float64{50, 75, 95, 99}
+
+// benchOptions defines options for running a benchmark.
+type benchOptions struct {
+	*globalOptions
+	publishers     int
+	consumers      int
+	size           int
+	rate           int
+	confirm        int
+	persistent     bool
+	prefetch       int
+	duration       time.Duration
+	reportInterval time.Duration
+	queue          string
+}
+
+// benchCommand creates the `buneary bench` command, making sure that exactly one
+// argument is passed.
+func benchCommand(options *globalOptions) *cobra.Command {
+	benchOptions := &benchOptions{
+		globalOptions: options,
+	}
+
+	bench := &cobra.Command{
+		Use:   "bench <ADDRESS>",
+		Short: "Run a load test with publishers and consumers",
+		Long: `Run a load test with publishers and consumers, each one on a separate connection.
+The throughput and the publish-to-consume latency are reported periodically and at the end.
+
+Unless --queue is specified, a temporary queue is declared and deleted after the run.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBench(benchOptions, args)
+		},
+	}
+
+	bench.Flags().
+		IntVar(&benchOptions.publishers, "publishers", 1, "the number of publishers")
+	bench.Flags().
+		IntVar(&benchOptions.consumers, "consumers", 1, "the number of consumers")
+	bench.Flags().
+		IntVar(&benchOptions.size, "size", 1000, "the message size in bytes")
+	bench.Flags().
+		IntVar(&benchOptions.rate, "rate", 0, "the total publishing rate in messages per second, 0 for unlimited")
+	bench.Flags().
+		IntVar(&benchOptions.confirm, "confirm", 0, "the maximum number of unconfirmed messages per publisher, 0 to disable confirms")
+	bench.Flags().
+		BoolVar(&benchOptions.persistent, "persistent", false, "publish persistent messages")
+	bench.Flags().
+		IntVar(&benchOptions.prefetch, "prefetch", 0, "the prefetch count of each consumer, 0 for unlimited")
+	bench.Flags().
+		DurationVar(&benchOptions.duration, "duration", 30*time.Second, "the duration of the run")
+	bench.Flags().
+		DurationVar(&benchOptions.reportInterval, "report-interval", time.Second, "the interval for printing intermediate results")
+	bench.Flags().
+		StringVar(&benchOptions.queue, "queue", "", "an existing, empty queue to use instead of a temporary one")
+
+	return bench
+}
+
+// benchStats collects the results of a benchmark. The counters are updated atomically
+// by the publishers and consumers, the latencies are protected by a mutex.
+type benchStats struct {
+	published int64
+	confirmed int64
+	nacked    int64
+	consumed  int64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	interval  []time.Duration
+}
+
+// addLatency records the latency of a consumed message.
+func (s *benchStats) addLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latencies = append(s.latencies, latency)
+	s.interval = append(s.interval, latency)
+}
+
+// takeInterval returns all latencies recorded since the last call.
+func (s *benchStats) takeInterval() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	interval := s.interval
+	s.interval = nil
+
+	return interval
+}
+
+// runBench runs a benchmark by reading the command line data, setting up the queue
+// and starting all publishers and consumers. In case the password or both the user
+// and password aren't provided, it will go into interactive mode.
+//
+// The run ends after the configured duration or once the user hits Ctrl-C. Either
+// way, the final results are printed.
+func runBench(options *benchOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	switch {
+	case options.dryRun:
+		return fmt.Errorf("running load test: %w", buneary.ErrDryRun)
+	case options.publishers < 0 || options.consumers < 0:
+		return errors.New("the number of publishers and consumers must not be negative")
+	case options.publishers == 0 && options.consumers == 0:
+		return errors.New("at least one publisher or consumer is required")
+	case options.size < benchTimestampSize:
+		return fmt.Errorf("the message size has to be at least %d bytes", benchTimestampSize)
+	case options.duration <= 0 || options.reportInterval <= 0:
+		return errors.New("the duration and report interval have to be positive")
+	}
+
+	config, err := newConfig(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	// Dialling is limited by the global timeout, but the connections are kept open
+	// for the entire run.
+	dial := func() (*amqp.Connection, error) {
+		if config.Timeout <= 0 {
+			return config.Dial(options.ctx)
+		}
+
+		ctx, cancel := context.WithTimeout(options.ctx, config.Timeout)
+		defer cancel()
+
+		return config.Dial(ctx)
+	}
+
+	setupConn, err := dial()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = setupConn.Close()
+	}()
+
+	setupChannel, err := setupConn.Channel()
+	if err != nil {
+		return fmt.Errorf("establishing AMQP channel: %w", err)
+	}
+
+	run := strconv.FormatInt(time.Now().UnixNano(), 10)
+	queue := options.queue
+
+	if queue == "" {
+		queue = "buneary-bench-" + run
+
+		if _, err := setupChannel.QueueDeclare(queue, false, true, false, false, nil); err != nil {
+			return fmt.Errorf("declaring queue: %w", err)
+		}
+
+		defer func() {
+			_, _ = setupChannel.QueueDelete(queue, false, false, false)
+		}()
+	} else {
+		existing, err := setupChannel.QueueInspect(queue)
+		if err != nil {
+			return fmt.Errorf("inspecting queue: %w", err)
+		}
+
+		// The consumers would have to receive and requeue all existing messages.
+		if existing.Messages > 0 {
+			return fmt.Errorf("queue %s contains %d messages, use an empty queue", queue, existing.Messages)
+		}
+	}
+
+	done := make(chan struct{})
+	stats := &benchStats{}
+
+	var wg sync.WaitGroup
+	var connections []*amqp.Connection
+
+	defer func() {
+		for _, conn := range connections {
+			_ = conn.Close()
+		}
+	}()
+
+	for i := 0; i < options.consumers+options.publishers; i++ {
+		conn, err := dial()
+		if err != nil {
+			return err
+		}
+		connections = append(connections, conn)
+	}
+
+	for i := 0; i < options.consumers; i++ {
+		if err := startBenchConsumer(connections[i], queue, run, options, stats, done, &wg); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < options.publishers; i++ {
+		conn := connections[options.consumers+i]
+		if err := startBenchPublisher(conn, queue, run, options, stats, done, &wg); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(options.duration)
+	ticker := time.NewTicker(options.reportInterval)
+
+	defer timer.Stop()
+	defer ticker.Stop()
+
+	var lastPublished, lastConsumed int64
+	lastReport := start
+
+loop:
+	for {
+		select {
+		case <-timer.C:
+			break loop
+		case <-options.ctx.Done():
+			break loop
+		case now := <-ticker.C:
+			published := atomic.LoadInt64(&stats.published)
+			consumed := atomic.LoadInt64(&stats.consumed)
+			elapsed := now.Sub(lastReport).Seconds()
+
+			output := fmt.Sprintf("time %s, sent %.0f msg/s, received %.0f msg/s, latency %s\n",
+				now.Sub(start).Truncate(time.Second),
+				float64(published-lastPublished)/elapsed,
+				float64(consumed-lastConsumed)/elapsed,
+				latenciesToString(stats.takeInterval()),
+			)
+			_, _ = options.out.WriteString(output)
+
+			lastPublished, lastConsumed, lastReport = published, consumed, now
+		}
+	}
+
+	close(done)
+	elapsed := time.Since(start)
+
+	// Closing the connections terminates all pending publishes and deliveries, so
+	// that all goroutines return.
+	for _, conn := range connections {
+		_ = conn.Close()
+	}
+	wg.Wait()
+
+	printBenchSummary(options, stats, elapsed)
+
+	return nil
+}
+
+// startBenchConsumer starts a consumer on its own channel of the given connection.
+// The consumer acknowledges the messages published by the given run and records their
+// latency until done is closed or the delivery channel is closed. Other messages are
+// rejected and requeued, so that they remain in the queue.
+func startBenchConsumer(conn *amqp.Connection, queue, run string, options *benchOptions, stats *benchStats,
+	done <-chan struct{}, wg *sync.WaitGroup) error {
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("establishing AMQP channel: %w", err)
+	}
+
+	if err := channel.Qos(options.prefetch, 0, false); err != nil {
+		return fmt.Errorf("setting prefetch count: %w", err)
+	}
+
+	deliveries, err := channel.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consuming from queue: %w", err)
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-done:
+				return
+			case delivery, ok := <-deliveries:
+				if !ok {
+					return
+				}
+				if id, _ := delivery.Headers[benchHeader].(string); id != run || len(delivery.Body) < benchTimestampSize {
+					_ = delivery.Nack(false, true)
+					continue
+				}
+				_ = delivery.Ack(false)
+				sent := int64(binary.BigEndian.Uint64(delivery.Body))
+				stats.addLatency(time.Duration(time.Now().UnixNano() - sent))
+				atomic.AddInt64(&stats.consumed, 1)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// startBenchPublisher starts a publisher on its own channel of the given connection.
+// The publisher sends messages tagged with the given run to the default exchange,
+// which routes them directly to the queue, until done is closed.
+//
+// If confirms are enabled, the publisher blocks as soon as the maximum number of
+// unconfirmed messages has been reached.
+func startBenchPublisher(conn *amqp.Connection, queue, run string, options *benchOptions, stats *benchStats,
+	done <-chan struct{}, wg *sync.WaitGroup) error {
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("establishing AMQP channel: %w", err)
+	}
+
+	var outstanding chan struct{}
+
+	if options.confirm > 0 {
+		if err := channel.Confirm(false); err != nil {
+			return fmt.Errorf("enabling publisher confirms: %w", err)
+		}
+
+		outstanding = make(chan struct{}, options.confirm)
+		confirms := channel.NotifyPublish(make(chan amqp.Confirmation, options.confirm))
+
+		go func() {
+			for confirmation := range confirms {
+				<-outstanding
+				if confirmation.Ack {
+					atomic.AddInt64(&stats.confirmed, 1)
+				} else {
+					atomic.AddInt64(&stats.nacked, 1)
+				}
+			}
+		}()
+	}
+
+	deliveryMode := amqp.Transient
+	if options.persistent {
+		deliveryMode = amqp.Persistent
+	}
+
+	// Each publisher is responsible for an equal share of the total rate.
+	var interval time.Duration
+	if options.rate > 0 {
+		interval = time.Duration(float64(time.Second) * float64(options.publishers) / float64(options.rate))
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		body := make([]byte, options.size)
+		next := time.Now()
+
+		for {
+			if interval > 0 {
+				next = next.Add(interval)
+				if wait := time.Until(next); wait > 0 {
+					select {
+					case <-done:
+						return
+					case <-time.After(wait):
+					}
+				}
+			}
+
+			if outstanding != nil {
+				select {
+				case <-done:
+					return
+				case outstanding <- struct{}{}:
+				}
+			}
+
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			binary.BigEndian.PutUint64(body, uint64(time.Now().UnixNano()))
+
+			err := channel.Publish("", queue, false, false, amqp.Publishing{
+				Headers:      amqp.Table{benchHeader: run},
+				DeliveryMode: deliveryMode,
+				Timestamp:    time.Now(),
+				Body:         body,
+			})
+			if err != nil {
+				return
+			}
+
+			atomic.AddInt64(&stats.published, 1)
+		}
+	}()
+
+	return nil
+}
+
+// printBenchSummary prints the final results of a benchmark.
+func printBenchSummary(options *benchOptions, stats *benchStats, elapsed time.Duration) {
+	var (
+		seconds   = elapsed.Seconds()
+		published = atomic.LoadInt64(&stats.published)
+		consumed  = atomic.LoadInt64(&stats.consumed)
+	)
+
+	lines := []string{
+		"",
+		fmt.Sprintf("duration:  %s", elapsed.Truncate(time.Millisecond)),
+		fmt.Sprintf("published: %d (%.0f msg/s)", published, float64(published)/seconds),
+		fmt.Sprintf("consumed:  %d (%.0f msg/s)", consumed, float64(consumed)/seconds),
+	}
+
+	if options.confirm > 0 {
+		lines = append(lines, fmt.Sprintf("confirmed: %d, nacked: %d",
+			atomic.LoadInt64(&stats.confirmed), atomic.LoadInt64(&stats.nacked)))
+	}
+
+	stats.mu.Lock()
+	lines = append(lines, fmt.Sprintf("latency:   %s", latenciesToString(stats.latencies)))
+	stats.mu.Unlock()
+
+	_, _ = options.out.WriteString(strings.Join(lines, "\n") + "\n")
+}
+
+// latenciesToString formats the minimum, maximum and benchPercentiles of the given
+// latencies, e.g. `min 1ms, p50 2ms, p75 3ms, p95 5ms, p99 8ms, max 12ms`.
+func latenciesToString(latencies []time.Duration) string {
+	if len(latencies) == 0 {
+		return "n/a"
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	tokens := []string{fmt.Sprintf("min %s", roundLatency(sorted[0]))}
+
+	for _, p := range benchPercentiles {
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		tokens = append(tokens, fmt.Sprintf("p%.0f %s", p, roundLatency(sorted[i])))
+	}
+
+	tokens = append(tokens, fmt.Sprintf("max %s", roundLatency(sorted[len(sorted)-1])))
+
+	return strings.Join(tokens, ", ")
+}
+
+// roundLatency rounds the given latency to microseconds for readability.
+func roundLatency(latency time.Duration) time.Duration {
+	return latency.Round(time.Microsecond)
+}
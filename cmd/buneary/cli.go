@@ -0,0 +1,2246 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var version = "UNDEFINED"
+
+const (
+	// outputTable is the default output format, rendering resources as a table.
+	outputTable = "table"
+
+	// outputJSON renders resources as indented JSON, suitable for scripting.
+	outputJSON = "json"
+)
+
+// The formats for printing message bodies. All of them except for bodyText and
+// bodyJSONPretty are safe to use with binary bodies.
+const (
+	bodyText       = "text"
+	bodyHex        = "hex"
+	bodyBase64     = "base64"
+	bodyHexdump    = "hexdump"
+	bodyJSONPretty = "json-pretty"
+)
+
+// globalOptions defines global command line options available for all commands.
+// They're read by the top-level command and passed to the sub-command factories.
+//
+// ctx is the context of the running command. It is cancelled once the user hits
+// Ctrl-C and should be passed to all Provider functions. session is only set for
+// commands running in a `buneary shell` session.
+type globalOptions struct {
+	user             string
+	password         string
+	passwordFile     string
+	passwordStdin    bool
+	credentialHelper string
+	token            string
+	tokenFile        string
+	tokenURL         string
+	clientID         string
+	clientSecret     string
+	scopes           []string
+	cert             string
+	key              string
+	caCert           string
+	dryRun           bool
+	output           string
+	config           string
+	vhost            string
+	timeout          time.Duration
+	ctx              context.Context
+	out              io.StringWriter
+	session          *session
+}
+
+// exitError is returned by commands that need to terminate with a particular exit
+// code. Its Error message is empty since these commands print their own output.
+type exitError struct {
+	code int
+}
+
+// Error implements the error interface.
+func (e *exitError) Error() string {
+	return ""
+}
+
+// rootCommand creates the top-level `buneary` command without any functionality. For
+// commands running in a shell session, the session has to be passed. Otherwise, it is
+// nil.
+func rootCommand(session *session) *cobra.Command {
+	options := globalOptions{
+		ctx:     context.Background(),
+		out:     os.Stdout,
+		session: session,
+	}
+
+	root := &cobra.Command{
+		Use:   "buneary",
+		Short: "An easy-to-use CLI client for RabbitMQ.",
+		Long: `buneary, pronounced bun-ear-y, is an easy-to-use RabbitMQ command line client
+for managing exchanges, managing queues, publishing messages to exchanges and reading messages from queues.`,
+		Version:       version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			options.ctx = cmd.Context()
+			if options.timeout < 0 {
+				return errors.New("the timeout must not be negative")
+			}
+			if options.output != outputTable && options.output != outputJSON {
+				return fmt.Errorf("unsupported output format: %s", options.output)
+			}
+			if countSet(options.password != "", options.passwordFile != "", options.passwordStdin) > 1 {
+				return errors.New("--password, --password-file and --password-stdin can't be used together")
+			}
+			if countSet(options.token != "", options.tokenFile != "", options.tokenURL != "") > 1 {
+				return errors.New("--token, --token-file and --token-url can't be used together")
+			}
+			if options.tokenURL != "" && options.clientID == "" {
+				return errors.New("--client-id is required when using --token-url")
+			}
+			if options.tokenURL == "" && (options.clientID != "" || options.clientSecret != "" || len(options.scopes) > 0) {
+				return errors.New("--client-id, --client-secret and --scopes require --token-url")
+			}
+			if (options.cert == "") != (options.key == "") {
+				return errors.New("--cert and --key have to be used together")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	root.AddCommand(createCommand(&options))
+	root.AddCommand(getCommand(&options))
+	root.AddCommand(publishCommand(&options))
+	root.AddCommand(rpcCommand(&options))
+	root.AddCommand(dumpCommand(&options))
+	root.AddCommand(restoreCommand(&options))
+	root.AddCommand(overviewCommand(&options))
+	root.AddCommand(checkCommand(&options))
+	root.AddCommand(topCommand(&options))
+	root.AddCommand(benchCommand(&options))
+	root.AddCommand(deleteCommand(&options))
+	root.AddCommand(versionCommand(&options))
+	root.AddCommand(completionCommand(&options))
+
+	// Shell sessions can't be nested.
+	if session == nil {
+		root.AddCommand(shellCommand(&options))
+	}
+
+	root.PersistentFlags().
+		StringVarP(&options.user, "user", "u", "", "the username to connect with")
+	root.PersistentFlags().
+		StringVarP(&options.password, "password", "p", "", "the password to authenticate with")
+	root.PersistentFlags().
+		StringVar(&options.passwordFile, "password-file", "", "read the password from a file")
+	root.PersistentFlags().
+		BoolVar(&options.passwordStdin, "password-stdin", false, "read the password from stdin")
+	root.PersistentFlags().
+		StringVar(&options.credentialHelper, "credential-helper", "", "the command for obtaining missing credentials")
+	root.PersistentFlags().
+		StringVar(&options.token, "token", "", "the OAuth2 access token to authenticate with")
+	root.PersistentFlags().
+		StringVar(&options.tokenFile, "token-file", "", "read the OAuth2 access token from a file")
+	root.PersistentFlags().
+		StringVar(&options.tokenURL, "token-url", "", "the OAuth2 token endpoint for obtaining access tokens")
+	root.PersistentFlags().
+		StringVar(&options.clientID, "client-id", "", "the OAuth2 client ID for --token-url")
+	root.PersistentFlags().
+		StringVar(&options.clientSecret, "client-secret", "", "the OAuth2 client secret for --token-url")
+	root.PersistentFlags().
+		StringSliceVar(&options.scopes, "scopes", nil, "the OAuth2 scopes for --token-url, e.g. rabbitmq.read:*/*")
+	root.PersistentFlags().
+		StringVar(&options.cert, "cert", "", "the PEM-encoded client certificate for authenticating AMQP connections")
+	root.PersistentFlags().
+		StringVar(&options.key, "key", "", "the PEM-encoded private key of the client certificate")
+	root.PersistentFlags().
+		StringVar(&options.caCert, "cacert", "", "the PEM-encoded CA certificates for verifying the server, enables TLS for AMQP")
+	root.PersistentFlags().
+		StringVarP(&options.output, "output", "o", outputTable, "the output format, either table or json")
+	root.PersistentFlags().
+		StringVar(&options.vhost, "vhost", "", "the virtual host, defaults to /")
+	root.PersistentFlags().
+		DurationVar(&options.timeout, "timeout", 0, "the timeout for each request to the server, e.g. 10s")
+	root.PersistentFlags().
+		StringVar(&options.config, "config", "", "the configuration file, defaults to buneary/config.json in the user config directory")
+	root.PersistentFlags().
+		BoolVar(&options.dryRun, "dry-run", false, "print the operations instead of changing anything on the server")
+
+	_ = root.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{outputTable, outputJSON}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return root
+}
+
+// createCommand creates the `buneary create` command without any functionality.
+func createCommand(options *globalOptions) *cobra.Command {
+	create := &cobra.Command{
+		Use:   "create <COMMAND>",
+		Short: "Create a resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	create.AddCommand(createExchangeCommand(options))
+	create.AddCommand(createQueueCommand(options))
+	create.AddCommand(createBindingCommand(options))
+	create.AddCommand(createShovelCommand(options))
+	create.AddCommand(createFederationUpstreamCommand(options))
+
+	return create
+}
+
+// createExchangeOptions defines options for creating a new exchange.
+type createExchangeOptions struct {
+	*globalOptions
+	durable    bool
+	autoDelete bool
+	internal   bool
+	noWait     bool
+}
+
+// createExchangeCommand creates the `buneary create exchange` command, making sure
+// that exactly three arguments are passed.
+//
+// At the moment, there is no support for setting Exchange.NoWait via this command.
+func createExchangeCommand(options *globalOptions) *cobra.Command {
+	createExchangeOptions := &createExchangeOptions{
+		globalOptions: options,
+	}
+
+	createExchange := &cobra.Command{
+		Use:               "exchange <ADDRESS> <NAME> <TYPE>",
+		Short:             "Create a new exchange",
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeArgs(options, completeAddresses, nil, completeExchangeTypes),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCreateExchange(createExchangeOptions, args)
+		},
+	}
+
+	createExchange.Flags().
+		BoolVar(&createExchangeOptions.durable, "durable", false, "make the exchange durable")
+	createExchange.Flags().
+		BoolVar(&createExchangeOptions.autoDelete, "auto-delete", false, "make the exchange auto-deleted")
+	createExchange.Flags().
+		BoolVar(&createExchangeOptions.internal, "internal", false, "make the exchange internal")
+
+	return createExchange
+}
+
+// runCreateExchange creates a new exchange by reading the command line data, setting
+// the configuration and calling the runCreateExchange function. In case the password
+// or both the user and password aren't provided, it will go into interactive mode.
+//
+// ToDo: Move the logic for parsing the exchange type into Exchange.
+func runCreateExchange(options *createExchangeOptions, args []string) error {
+	var (
+		address      = args[0]
+		name         = args[1]
+		exchangeType = args[2]
+	)
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	exchange := buneary.Exchange{
+		Name:       name,
+		Durable:    options.durable,
+		AutoDelete: options.autoDelete,
+		Internal:   options.internal,
+		NoWait:     options.noWait,
+	}
+
+	switch exchangeType {
+	case "direct":
+		exchange.Type = buneary.Direct
+	case "headers":
+		exchange.Type = buneary.Headers
+	case "fanout":
+		exchange.Type = buneary.Fanout
+	case "topic":
+		exchange.Type = buneary.Topic
+	}
+
+	if err := provider.CreateExchange(options.ctx, exchange); err != nil {
+		return err
+	}
+
+	printSuccess(options.globalOptions, "exchange created successfully\n")
+
+	return nil
+}
+
+// createQueueOptions defines options for creating a new queue.
+type createQueueOptions struct {
+	*globalOptions
+	durable    bool
+	autoDelete bool
+}
+
+// createQueueCommand creates the `buneary create queue` command, making sure that
+// exactly three arguments are passed.
+//
+// The <TYPE> argument may become optional for convenience in the future. In this
+// case, it should default to the classic queue type.
+func createQueueCommand(options *globalOptions) *cobra.Command {
+	createQueueOptions := &createQueueOptions{
+		globalOptions: options,
+	}
+
+	createQueue := &cobra.Command{
+		Use:               "queue <ADDRESS> <NAME> <TYPE>",
+		Short:             "Create a new queue",
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeArgs(options, completeAddresses, nil, completeQueueTypes),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCreateQueue(createQueueOptions, args)
+		},
+	}
+
+	createQueue.Flags().
+		BoolVar(&createQueueOptions.durable, "durable", false, "make the queue durable")
+	createQueue.Flags().
+		BoolVar(&createQueueOptions.autoDelete, "auto-delete", false, "make the queue auto-deleted")
+
+	return createQueue
+}
+
+// runCreateQueue creates a new queue by reading the command line data, setting the
+// configuration and calling the CreateQueue function. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+//
+// If the queue type is empty or invalid, the queue type defaults to Classic.
+func runCreateQueue(options *createQueueOptions, args []string) error {
+	var (
+		address   = args[0]
+		name      = args[1]
+		queueType = args[2]
+	)
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	queue := buneary.Queue{
+		Name:       name,
+		Durable:    options.durable,
+		AutoDelete: options.autoDelete,
+	}
+
+	switch queueType {
+	case "quorum":
+		queue.Type = buneary.Quorum
+	case "classic":
+		fallthrough
+	default:
+		queue.Type = buneary.Classic
+	}
+
+	_, err = provider.CreateQueue(options.ctx, queue)
+	if err != nil {
+		return err
+	}
+
+	printSuccess(options.globalOptions, "queue created successfully\n")
+
+	return nil
+}
+
+// createBindingOptions defines options for creating a new binding.
+type createBindingOptions struct {
+	*globalOptions
+	toExchange bool
+}
+
+// createBindingCommand creates the `buneary create binding` command, making sure
+// that exactly four arguments are passed.
+func createBindingCommand(options *globalOptions) *cobra.Command {
+	createBindingOptions := &createBindingOptions{
+		globalOptions: options,
+	}
+
+	createQueue := &cobra.Command{
+		Use:               "binding <ADDRESS> <NAME> <TARGET> <BINDING KEY>",
+		Short:             "Create a new binding",
+		Args:              cobra.ExactArgs(4),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeExchangeNames, completeBindingTargets(&createBindingOptions.toExchange)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCreateBinding(createBindingOptions, args)
+		},
+	}
+
+	createQueue.Flags().
+		BoolVar(&createBindingOptions.toExchange, "to-exchange", false, "the target is another exchange")
+
+	return createQueue
+}
+
+// runCreateBinding creates a new binding by reading the command line data, setting
+// the configuration and calling the CreateQueue function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// The binding type defaults to ToQueue. To create a binding to another exchange, the
+// --to-exchange flag has to be used.
+func runCreateBinding(options *createBindingOptions, args []string) error {
+	var (
+		address    = args[0]
+		name       = args[1]
+		target     = args[2]
+		bindingKey = args[3]
+	)
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	binding := buneary.Binding{
+		From:       buneary.Exchange{Name: name},
+		TargetName: target,
+		Key:        bindingKey,
+	}
+
+	switch options.toExchange {
+	case true:
+		binding.Type = buneary.ToExchange
+	default:
+		binding.Type = buneary.ToQueue
+	}
+
+	if err := provider.CreateBinding(options.ctx, binding); err != nil {
+		return err
+	}
+
+	printSuccess(options.globalOptions, "queue created successfully\n")
+
+	return nil
+}
+
+// getCommand creates the `buneary get` command without any functionality.
+func getCommand(options *globalOptions) *cobra.Command {
+	get := &cobra.Command{
+		Use:   "get <COMMAND>",
+		Short: "Get a resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	get.AddCommand(getExchangesCommand(options))
+	get.AddCommand(getExchangeCommand(options))
+	get.AddCommand(getQueuesCommand(options))
+	get.AddCommand(getQueueCommand(options))
+	get.AddCommand(getBindingsCommand(options))
+	get.AddCommand(getBindingCommand(options))
+	get.AddCommand(getMessagesCommand(options))
+	get.AddCommand(getNodesCommand(options))
+	get.AddCommand(getNodeCommand(options))
+	get.AddCommand(getShovelsCommand(options))
+	get.AddCommand(getShovelCommand(options))
+	get.AddCommand(getFederationUpstreamsCommand(options))
+	get.AddCommand(getFederationUpstreamCommand(options))
+	get.AddCommand(getFederationLinksCommand(options))
+
+	return get
+}
+
+// getExchangesOptions defines options for reading exchanges.
+type getExchangesOptions struct {
+	*globalOptions
+	wide bool
+}
+
+// getExchangesCommand creates the `buneary get exchanges` command, making sure that
+// exactly one argument is passed.
+func getExchangesCommand(options *globalOptions) *cobra.Command {
+	getExchangesOptions := &getExchangesOptions{
+		globalOptions: options,
+	}
+
+	getExchanges := &cobra.Command{
+		Use:               "exchanges <ADDRESS>",
+		Short:             "Get all available exchanges",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetExchanges(getExchangesOptions, args)
+		},
+	}
+
+	getExchanges.Flags().
+		BoolVar(&getExchangesOptions.wide, "wide", false, "show message rates and totals")
+
+	return getExchanges
+}
+
+// getExchangeCommand creates the `buneary get exchange` command, making sure that exactly
+// two arguments are passed.
+func getExchangeCommand(options *globalOptions) *cobra.Command {
+	getExchangeOptions := &getExchangesOptions{
+		globalOptions: options,
+	}
+
+	getExchange := &cobra.Command{
+		Use:               "exchange <ADDRESS> <NAME>",
+		Short:             "Get a single exchange",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeExchangeNames),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetExchanges(getExchangeOptions, args)
+		},
+	}
+
+	getExchange.Flags().
+		BoolVar(&getExchangeOptions.wide, "wide", false, "show message rates and totals")
+
+	return getExchange
+}
+
+// runGetExchanges either returns all exchanges or - if an exchange name has been
+// specified as second argument - a single exchange. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+//
+// This flexibility allows runGetExchanges to be used by both `buneary get exchanges`
+// as well as `buneary get exchange`.
+//
+// With the --wide flag, the publish-in and publish-out rates and totals are printed
+// as well. They're always included in the JSON output.
+func runGetExchanges(options *getExchangesOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	// The default filter will let pass all exchanges regardless of their names.
+	filter := func(_ buneary.Exchange) bool {
+		return true
+	}
+
+	// However, if an exchange name has been specified as second argument, only
+	// that particular exchange should be returned.
+	if len(args) > 1 {
+		filter = func(exchange buneary.Exchange) bool {
+			return exchange.Name == args[1]
+		}
+	}
+
+	exchanges, err := provider.GetExchanges(options.ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options.globalOptions, exchanges)
+	}
+
+	header := []string{"Name", "Type", "Durable", "Auto-Delete", "Internal"}
+
+	if options.wide {
+		header = append(header, "Publish In", "Publish In/s", "Publish Out", "Publish Out/s")
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+
+	for _, exchange := range exchanges {
+		row := make([]string, 5, len(header))
+		row[0] = exchange.Name
+		row[1] = string(exchange.Type)
+		row[2] = boolToString(exchange.Durable)
+		row[3] = boolToString(exchange.AutoDelete)
+		row[4] = boolToString(exchange.Internal)
+
+		if options.wide {
+			stats := exchange.MessageStats
+			row = append(row,
+				strconv.Itoa(stats.PublishIn),
+				rateToString(stats.PublishInRate),
+				strconv.Itoa(stats.PublishOut),
+				rateToString(stats.PublishOutRate),
+			)
+		}
+
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getQueuesCommand creates the `buneary get queues` command, making sure that
+// exactly one argument is passed.
+func getQueuesCommand(options *globalOptions) *cobra.Command {
+	getQueues := &cobra.Command{
+		Use:               "queues <ADDRESS>",
+		Short:             "Get all available queues",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetQueues(options, args)
+		},
+	}
+
+	return getQueues
+}
+
+// getQueueCommand creates the `buneary get queue` command, making sure that exactly two
+// arguments are passed.
+func getQueueCommand(options *globalOptions) *cobra.Command {
+	getQueue := &cobra.Command{
+		Use:               "queue <ADDRESS> <NAME>",
+		Short:             "Get a single queue",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeQueueNames),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetQueues(options, args)
+		},
+	}
+
+	return getQueue
+}
+
+// runGetQueues either returns all queues or - if a queue name has been specified as second
+// argument - a single queue. In case the password or both the user and password aren't
+// provided, it will go into interactive mode.
+//
+// This flexibility allows runGetQueues to be used by both `buneary get queues` as well as
+// `buneary get queue`.
+func runGetQueues(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	// The default filter will let pass all queues regardless of their names.
+	filter := func(_ buneary.Queue) bool {
+		return true
+	}
+
+	// However, if a queue name has been specified as second argument, only that
+	// particular queue should be returned.
+	if len(args) > 1 {
+		filter = func(queue buneary.Queue) bool {
+			return queue.Name == args[1]
+		}
+	}
+
+	queues, err := provider.GetQueues(options.ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options, queues)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Durable", "Auto-Delete"})
+
+	for _, queue := range queues {
+		row := make([]string, 3)
+		row[0] = queue.Name
+		row[1] = boolToString(queue.Durable)
+		row[2] = boolToString(queue.AutoDelete)
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getBindingsCommand creates the `buneary get bindings` command, making sure that
+// exactly one argument is passed.
+func getBindingsCommand(options *globalOptions) *cobra.Command {
+	getQueues := &cobra.Command{
+		Use:               "bindings <ADDRESS>",
+		Short:             "Get all available bindings",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetBindings(options, args)
+		},
+	}
+
+	return getQueues
+}
+
+// getBindingCommand creates the `buneary get binding` command, making sure that exactly
+// three arguments are passed.
+func getBindingCommand(options *globalOptions) *cobra.Command {
+	getQueue := &cobra.Command{
+		Use:               "binding <ADDRESS> <EXCHANGE NAME> <TARGET NAME>",
+		Short:             "Get the binding or bindings between two resources",
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeExchangeNames, completeTargetNames),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetBindings(options, args)
+		},
+	}
+
+	return getQueue
+}
+
+// runGetBindings either returns all bindings  or - if a queue name has been specified as second
+// argument - a single binding. In case the password or both the user and password aren't
+// provided, it will go into interactive mode.
+//
+// This flexibility allows runGetBindings to be used by both `buneary get bindings` as well as
+// `buneary get binding`.
+func runGetBindings(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	// The default filter will let pass all bindings regardless of their names.
+	filter := func(_ buneary.Binding) bool {
+		return true
+	}
+
+	// However, if a source exchange and a binding target have been specified as
+	// second argument, only that particular binding should be returned.
+	if len(args) > 2 {
+		filter = func(binding buneary.Binding) bool {
+			return binding.From.Name == args[1] &&
+				binding.TargetName == args[2]
+		}
+	}
+
+	bindings, err := provider.GetBindings(options.ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options, bindings)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"From", "Target", "Type", "Binding Key"})
+
+	for _, binding := range bindings {
+		row := make([]string, 4)
+		row[0] = binding.From.Name
+		row[1] = binding.TargetName
+		row[2] = string(binding.Type)
+		row[3] = binding.Key
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getNodesCommand creates the `buneary get nodes` command, making sure that exactly
+// one argument is passed.
+func getNodesCommand(options *globalOptions) *cobra.Command {
+	getNodes := &cobra.Command{
+		Use:               "nodes <ADDRESS>",
+		Short:             "Get all cluster nodes",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetNodes(options, args)
+		},
+	}
+
+	return getNodes
+}
+
+// getNodeCommand creates the `buneary get node` command, making sure that exactly two
+// arguments are passed.
+func getNodeCommand(options *globalOptions) *cobra.Command {
+	getNode := &cobra.Command{
+		Use:               "node <ADDRESS> <NAME>",
+		Short:             "Get a single cluster node",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetNodes(options, args)
+		},
+	}
+
+	return getNode
+}
+
+// runGetNodes either returns all cluster nodes or - if a node name has been specified
+// as second argument - a single node. In case the password or both the user and
+// password aren't provided, it will go into interactive mode.
+//
+// This flexibility allows runGetNodes to be used by both `buneary get nodes` as well
+// as `buneary get node`.
+func runGetNodes(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	// The default filter will let pass all nodes regardless of their names.
+	filter := func(_ buneary.Node) bool {
+		return true
+	}
+
+	// However, if a node name has been specified as second argument, only that
+	// particular node should be returned.
+	if len(args) > 1 {
+		filter = func(node buneary.Node) bool {
+			return node.Name == args[1]
+		}
+	}
+
+	nodes, err := provider.GetNodes(options.ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options, nodes)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Running", "Memory", "Disk Free", "File Descriptors", "Uptime", "Partitions"})
+
+	for _, node := range nodes {
+		row := make([]string, 7)
+		row[0] = node.Name
+		row[1] = boolToString(node.Running)
+		row[2] = fmt.Sprintf("%s / %s", bytesToString(node.MemUsed), bytesToString(node.MemLimit))
+		row[3] = bytesToString(node.DiskFree)
+		row[4] = fmt.Sprintf("%d / %d", node.FdUsed, node.FdTotal)
+		row[5] = node.Uptime.Truncate(time.Second).String()
+		row[6] = strings.Join(node.Partitions, ", ")
+
+		if node.MemAlarm {
+			row[2] += " (alarm)"
+		}
+		if node.DiskFreeAlarm {
+			row[3] += " (alarm)"
+		}
+
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getMessagesOptions defines options for reading messages.
+type getMessagesOptions struct {
+	*globalOptions
+	max        int
+	requeue    bool
+	force      bool
+	bodyFormat string
+	where      string
+	schema     string
+	protoFile  string
+	protoType  string
+	avroSchema string
+	raw        bool
+}
+
+// getMessagesCommand creates the `buneary get messages` command, making sure that exactly
+// two arguments are passed.
+func getMessagesCommand(options *globalOptions) *cobra.Command {
+	getMessagesOptions := &getMessagesOptions{
+		globalOptions: options,
+	}
+
+	getMessages := &cobra.Command{
+		Use:               "messages <ADDRESS> <QUEUE NAME>",
+		Short:             "Get messages in a queue",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeQueueNames),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetMessages(getMessagesOptions, args)
+		},
+	}
+
+	getMessages.Flags().
+		IntVar(&getMessagesOptions.max, "max", 1, "maximum messages to read")
+	getMessages.Flags().
+		BoolVar(&getMessagesOptions.requeue, "requeue", false, "re-queue the messages after reading them")
+	getMessages.Flags().
+		BoolVarP(&getMessagesOptions.force, "force", "f", false, "force running this command without opt-in")
+	getMessages.Flags().
+		StringVar(&getMessagesOptions.bodyFormat, "body-format", bodyText, "the body format: text, hex, base64, hexdump or json-pretty")
+	getMessages.Flags().
+		StringVar(&getMessagesOptions.where, "where", "", "only read messages matching the expression")
+	getMessages.Flags().
+		StringVar(&getMessagesOptions.schema, "schema", "", "flag messages that don't match this JSON Schema file")
+	getMessages.Flags().
+		StringVar(&getMessagesOptions.protoFile, "proto-file", "", "decode Protobuf bodies using this .proto file or descriptor set")
+	getMessages.Flags().
+		StringVar(&getMessagesOptions.protoType, "proto-type", "", "the fully-qualified Protobuf message type")
+	getMessages.Flags().
+		StringVar(&getMessagesOptions.avroSchema, "avro-schema", "", "decode Avro bodies using this schema file")
+	getMessages.Flags().
+		BoolVar(&getMessagesOptions.raw, "raw", false, "don't decompress bodies based on their content encoding")
+
+	return getMessages
+}
+
+// runGetMessages gets messages by reading the command line data, setting the
+// configuration and calling the GetMessages function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// If an expression has been passed using --where, the FindMessages function is
+// used instead, returning up to --max messages matching the expression.
+//
+// Messages are validated against the schema passed using --schema or against the
+// schemas mapped in the configuration file. Invalid messages are flagged, but they
+// are printed nonetheless.
+//
+// Unless --raw is passed, compressed bodies are decompressed according to their
+// content encoding. Protobuf and Avro bodies are then decoded to JSON before
+// validating and printing them. Bodies that can't be decoded are kept as they are.
+func runGetMessages(options *getMessagesOptions, args []string) error {
+	var (
+		address = args[0]
+		queue   = args[1]
+	)
+
+	if !isBodyFormat(options.bodyFormat) {
+		return fmt.Errorf("unsupported body format: %s", options.bodyFormat)
+	}
+
+	var (
+		where *expression
+		err   error
+	)
+
+	if options.where != "" {
+		if where, err = compileExpression(options.where); err != nil {
+			return fmt.Errorf("parsing --where expression: %w", err)
+		}
+	}
+
+	config, err := loadConfig(options.config)
+	if err != nil {
+		return err
+	}
+
+	validator := newSchemaValidator(options.schema, config)
+
+	codec, err := newPayloadCodec(options.protoFile, options.protoType, options.avroSchema)
+	if err != nil {
+		return err
+	}
+
+	message := "Reading the messages from the queue will de-queue them." +
+		"To re-queue them, pass the --requeue flag. Do you want to continue?"
+
+	// In dry-run mode, de-queueing the messages is refused anyway.
+	if !options.force && !options.dryRun {
+		ok, err := confirm(options.globalOptions, message)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+	}
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	var messages []buneary.Message
+
+	// Without an expression, the messages are read via the HTTP API. Otherwise, they
+	// have to be read via AMQP so that non-matching messages can be re-queued.
+	if where == nil {
+		messages, err = provider.GetMessages(options.ctx, buneary.Queue{Name: queue}, options.max, options.requeue)
+	} else {
+		// The expression is evaluated against the body as it is going to be printed.
+		matches := func(message buneary.Message) bool {
+			return where.matches(decodeMessage(message, options.raw, codec))
+		}
+		messages, err = provider.FindMessages(options.ctx, buneary.Queue{Name: queue}, options.max, options.requeue, matches)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, message := range messages {
+		messages[i] = decodeMessage(message, options.raw, codec)
+	}
+
+	// In the JSON output, the body is formatted according to --body-format just like
+	// in the table instead of always being encoded as Base64.
+	type formattedMessage struct {
+		buneary.Message
+		Body string `json:"body"`
+	}
+
+	formatted := make([]formattedMessage, len(messages))
+
+	for i, message := range messages {
+		formatted[i] = formattedMessage{Message: message, Body: formatBody(message.Body, options.bodyFormat)}
+	}
+
+	if !validator.enabled() {
+		if options.output == outputJSON {
+			return printJSON(options.globalOptions, formatted)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Exchange", "Routing Key", "Body"})
+
+		for _, message := range formatted {
+			row := make([]string, 3)
+			row[0] = message.Target.Name
+			row[1] = message.RoutingKey
+			row[2] = message.Body
+			table.Append(row)
+		}
+
+		table.Render()
+
+		return nil
+	}
+
+	type validatedMessage struct {
+		formattedMessage
+		SchemaErrors []string `json:"schema_errors"`
+	}
+
+	validated := make([]validatedMessage, len(messages))
+
+	for i, message := range formatted {
+		errs, err := validator.validate(message.Message)
+		if err != nil {
+			return err
+		}
+		validated[i] = validatedMessage{formattedMessage: message, SchemaErrors: errs}
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options.globalOptions, validated)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Exchange", "Routing Key", "Body", "Schema"})
+
+	for _, message := range validated {
+		row := make([]string, 4)
+		row[0] = message.Target.Name
+		row[1] = message.RoutingKey
+		row[2] = message.Body
+		row[3] = "valid"
+		if len(message.SchemaErrors) > 0 {
+			row[3] = "invalid: " + strings.Join(message.SchemaErrors, "; ")
+		}
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// decodeMessage returns the given message with its body decompressed according to
+// its content encoding unless raw is set, and decoded using the given codec if it
+// isn't nil. Bodies that can't be decompressed or decoded are kept as they are.
+func decodeMessage(message buneary.Message, raw bool, codec payloadCodec) buneary.Message {
+	if !raw {
+		body, err := decompressBody(message.Body, message.Properties.ContentEncoding)
+		if err != nil {
+			return message
+		}
+		message.Body = body
+	}
+
+	if codec != nil {
+		if body, err := codec.decode(message.Body); err == nil {
+			message.Body = body
+		}
+	}
+
+	return message
+}
+
+// publishOptions defines options for publishing a message.
+type publishOptions struct {
+	*globalOptions
+	headers    string
+	schema     string
+	protoFile  string
+	protoType  string
+	avroSchema string
+	compress   string
+	template   string
+	count      int
+	data       string
+}
+
+// publishCommand creates the `buneary publish` command, making sure that three or
+// four command arguments are passed. The body may only be omitted if a template
+// file is used instead.
+func publishCommand(options *globalOptions) *cobra.Command {
+	publishOptions := &publishOptions{
+		globalOptions: options,
+	}
+
+	publish := &cobra.Command{
+		Use:               "publish <ADDRESS> <EXCHANGE> <ROUTING KEY> [<BODY>]",
+		Short:             "Publish a message to an exchange",
+		Args:              cobra.RangeArgs(3, 4),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeExchangeNames),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPublish(publishOptions, args)
+		},
+	}
+
+	publish.Flags().
+		StringVar(&publishOptions.headers, "headers", "", "headers as comma-separated key-value pairs")
+	publish.Flags().
+		StringVar(&publishOptions.schema, "schema", "", "validate the body against this JSON Schema file")
+	publish.Flags().
+		StringVar(&publishOptions.protoFile, "proto-file", "", "encode the JSON body as Protobuf using this .proto file or descriptor set")
+	publish.Flags().
+		StringVar(&publishOptions.protoType, "proto-type", "", "the fully-qualified Protobuf message type")
+	publish.Flags().
+		StringVar(&publishOptions.avroSchema, "avro-schema", "", "encode the JSON body as Avro using this schema file")
+	publish.Flags().
+		StringVar(&publishOptions.compress, "compress", "", "compress the body using gzip or zstd")
+	publish.Flags().
+		StringVar(&publishOptions.template, "template", "", "generate the body from this template file")
+	publish.Flags().
+		IntVar(&publishOptions.count, "count", 1, "the number of messages to publish")
+	publish.Flags().
+		StringVar(&publishOptions.data, "data", "", "a CSV or JSON file with values for the templates")
+
+	return publish
+}
+
+// runPublish publishes a message by reading the command line data, setting the
+// configuration and calling the PublishMessage function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// If a template file is passed using --template, the routing key, the headers and
+// the body are treated as Go templates and rendered for each message. In this case,
+// or if --count is greater than 1, all messages are published using the
+// PublishMessages function.
+func runPublish(options *publishOptions, args []string) error {
+	var (
+		address    = args[0]
+		exchange   = args[1]
+		routingKey = args[2]
+		body       string
+	)
+
+	switch {
+	case len(args) == 4 && options.template != "":
+		return errors.New("a body can't be passed when using --template")
+	case len(args) == 4:
+		body = args[3]
+	case options.template == "":
+		return errors.New("either a body or --template is required")
+	}
+
+	if options.count < 1 {
+		return errors.New("the message count has to be positive")
+	}
+
+	if options.data != "" && options.template == "" {
+		return errors.New("--data requires --template")
+	}
+
+	if options.compress != "" && options.compress != encodingGzip && options.compress != encodingZstd {
+		return fmt.Errorf("unsupported compression: %s", options.compress)
+	}
+
+	config, err := loadConfig(options.config)
+	if err != nil {
+		return err
+	}
+
+	validator := newSchemaValidator(options.schema, config)
+
+	codec, err := newPayloadCodec(options.protoFile, options.protoType, options.avroSchema)
+	if err != nil {
+		return err
+	}
+
+	var tmpl *messageTemplate
+
+	if options.template != "" {
+		source, err := ioutil.ReadFile(options.template)
+		if err != nil {
+			return fmt.Errorf("reading template: %w", err)
+		}
+
+		if tmpl, err = newMessageTemplate(routingKey, options.headers, string(source), options.data); err != nil {
+			return err
+		}
+	}
+
+	messages := make([]buneary.Message, options.count)
+
+	for i := range messages {
+		var (
+			messageRoutingKey = routingKey
+			messageHeaders    = make(map[string]interface{})
+			messageBody       = body
+		)
+
+		if tmpl != nil {
+			messageRoutingKey, messageHeaders, messageBody, err = tmpl.execute(i + 1)
+			if err != nil {
+				return fmt.Errorf("generating message %d: %w", i+1, err)
+			}
+		} else if err := parseHeaders(options.headers, messageHeaders); err != nil {
+			return err
+		}
+
+		message := buneary.Message{
+			Target:     buneary.Exchange{Name: exchange},
+			Headers:    messageHeaders,
+			RoutingKey: messageRoutingKey,
+			Body:       []byte(messageBody),
+			Properties: buneary.MessageProperties{
+				Timestamp: time.Now(),
+			},
+		}
+
+		if messages[i], err = prepareMessage(options, validator, codec, message); err != nil {
+			if options.count > 1 {
+				return fmt.Errorf("message %d: %w", i+1, err)
+			}
+			return err
+		}
+	}
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	if len(messages) == 1 {
+		if err := provider.PublishMessage(options.ctx, messages[0]); err != nil {
+			return err
+		}
+
+		printSuccess(options.globalOptions, "message published successfully\n")
+
+		return nil
+	}
+
+	count, err := provider.PublishMessages(options.ctx, messages)
+	if err != nil {
+		return err
+	}
+
+	output := fmt.Sprintf("%d messages published successfully\n", count)
+	printSuccess(options.globalOptions, output)
+
+	return nil
+}
+
+// prepareMessage prepares a message for publishing. First, the body is validated
+// against the schema passed using --schema or against the schema mapped to the
+// exchange and routing key in the configuration file. Invalid messages are refused.
+//
+// If a Protobuf message type or an Avro schema is given, the body is expected to be
+// JSON and gets encoded after the validation. Finally, the body is compressed if
+// requested, setting the content encoding accordingly.
+func prepareMessage(options *publishOptions, validator *schemaValidator, codec payloadCodec, message buneary.Message) (buneary.Message, error) {
+	errs, err := validator.validate(message)
+	if err != nil {
+		return buneary.Message{}, err
+	}
+
+	if len(errs) > 0 {
+		return buneary.Message{}, &schemaError{errs: errs}
+	}
+
+	if codec != nil {
+		if message.Body, err = codec.encode(message.Body); err != nil {
+			return buneary.Message{}, err
+		}
+		message.Properties.ContentType = codec.contentType()
+	}
+
+	if options.compress != "" {
+		if message.Body, err = compressBody(message.Body, options.compress); err != nil {
+			return buneary.Message{}, err
+		}
+		message.Properties.ContentEncoding = options.compress
+	}
+
+	return message, nil
+}
+
+// rpcOptions defines options for sending an RPC request.
+type rpcOptions struct {
+	*globalOptions
+	headers       string
+	contentType   string
+	correlationID string
+	replyTimeout  time.Duration
+	tempQueue     bool
+}
+
+// rpcCommand creates the `buneary rpc` command, making sure that exactly four command
+// arguments are passed.
+func rpcCommand(options *globalOptions) *cobra.Command {
+	rpcOptions := &rpcOptions{
+		globalOptions: options,
+	}
+
+	rpc := &cobra.Command{
+		Use:               "rpc <ADDRESS> <EXCHANGE> <ROUTING KEY> <BODY>",
+		Short:             "Send an RPC request and wait for the reply",
+		Args:              cobra.ExactArgs(4),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeExchangeNames),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRPC(rpcOptions, args)
+		},
+	}
+
+	rpc.Flags().
+		StringVar(&rpcOptions.headers, "headers", "", "headers as comma-separated key-value pairs")
+	rpc.Flags().
+		StringVar(&rpcOptions.contentType, "content-type", "", "the content type of the request")
+	rpc.Flags().
+		StringVar(&rpcOptions.correlationID, "correlation-id", "", "the correlation ID, generated if empty")
+	rpc.Flags().
+		DurationVar(&rpcOptions.replyTimeout, "reply-timeout", 10*time.Second, "the time to wait for the reply")
+	rpc.Flags().
+		BoolVar(&rpcOptions.tempQueue, "temp-queue", false, "receive the reply from a temporary queue instead of Direct Reply-to")
+
+	return rpc
+}
+
+// runRPC sends an RPC request by reading the command line data, setting the
+// configuration and calling the RequestReply function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// The reply body is printed along with all reply properties and headers.
+func runRPC(options *rpcOptions, args []string) error {
+	var (
+		address    = args[0]
+		exchange   = args[1]
+		routingKey = args[2]
+		body       = args[3]
+	)
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	request := buneary.Message{
+		Target:     buneary.Exchange{Name: exchange},
+		Headers:    make(map[string]interface{}),
+		RoutingKey: routingKey,
+		Body:       []byte(body),
+		Properties: buneary.MessageProperties{
+			ContentType:   options.contentType,
+			CorrelationID: options.correlationID,
+			Timestamp:     time.Now(),
+		},
+	}
+
+	if err := parseHeaders(options.headers, request.Headers); err != nil {
+		return err
+	}
+
+	reply, err := provider.RequestReply(options.ctx, request, !options.tempQueue, options.replyTimeout)
+	if err != nil {
+		return err
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options.globalOptions, reply)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.AppendBulk(propertiesToRows(reply))
+	table.Append([]string{"Body", string(reply.Body)})
+	table.Render()
+
+	return nil
+}
+
+// overviewCommand creates the `buneary overview` command, making sure that exactly
+// one argument is passed.
+func overviewCommand(options *globalOptions) *cobra.Command {
+	overview := &cobra.Command{
+		Use:               "overview <ADDRESS>",
+		Short:             "Get an overview of the cluster",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runOverview(options, args)
+		},
+	}
+
+	return overview
+}
+
+// runOverview prints the cluster overview by reading the command line data, setting
+// the configuration and calling the GetOverview function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+func runOverview(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	overview, err := provider.GetOverview(options.ctx)
+	if err != nil {
+		return err
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options, overview)
+	}
+
+	alarms := "none"
+
+	if len(overview.Alarms) > 0 {
+		tokens := make([]string, len(overview.Alarms))
+		for i, alarm := range overview.Alarms {
+			tokens[i] = fmt.Sprintf("%s on %s", alarm.Resource, alarm.Node)
+		}
+		alarms = strings.Join(tokens, ", ")
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.AppendBulk([][]string{
+		{"Cluster", overview.ClusterName},
+		{"RabbitMQ Version", overview.RabbitMQVersion},
+		{"Erlang Version", overview.ErlangVersion},
+		{"Connections", strconv.Itoa(overview.Connections)},
+		{"Channels", strconv.Itoa(overview.Channels)},
+		{"Exchanges", strconv.Itoa(overview.Exchanges)},
+		{"Queues", strconv.Itoa(overview.Queues)},
+		{"Consumers", strconv.Itoa(overview.Consumers)},
+		{"Messages", strconv.Itoa(overview.Messages)},
+		{"Messages Ready", strconv.Itoa(overview.MessagesReady)},
+		{"Messages Unacked", strconv.Itoa(overview.MessagesUnacknowledged)},
+		{"Publish/s", rateToString(overview.PublishRate)},
+		{"Deliver/s", rateToString(overview.DeliverRate)},
+		{"Alarms", alarms},
+	})
+
+	table.Render()
+
+	return nil
+}
+
+// The check states and their exit codes as defined by the Nagios plugin guidelines.
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	checkUnknown  = 3
+)
+
+// checkStates maps the check states to their names printed in the summary.
+var checkStates = map[int]string{
+	checkOK:       "OK",
+	checkWarning:  "WARNING",
+	checkCritical: "CRITICAL",
+	checkUnknown:  "UNKNOWN",
+}
+
+// checkSeverities ranks the check states. The state with the highest severity
+// determines the overall state. Note that CRITICAL outranks UNKNOWN.
+var checkSeverities = map[int]int{
+	checkOK:       0,
+	checkWarning:  1,
+	checkUnknown:  2,
+	checkCritical: 3,
+}
+
+// checkOptions defines options for running health checks.
+type checkOptions struct {
+	*globalOptions
+	port         int
+	within       int
+	withinUnit   string
+	queue        string
+	maxReady     int
+	warnReady    int
+	minConsumers int
+}
+
+// checkCommand creates the `buneary check` command, making sure that at least one
+// argument is passed. All arguments after the address are health checks.
+func checkCommand(options *globalOptions) *cobra.Command {
+	checkOptions := &checkOptions{
+		globalOptions: options,
+	}
+
+	checks := make([]string, len(buneary.HealthChecks))
+	for i, check := range buneary.HealthChecks {
+		checks[i] = string(check)
+	}
+
+	check := &cobra.Command{
+		Use:   "check <ADDRESS> [CHECK...]",
+		Short: "Run health checks with monitoring-friendly exit codes",
+		Long: fmt.Sprintf(`Run health checks and print a one-line summary. The exit code follows the Nagios
+convention: 0 for OK, 1 for WARNING, 2 for CRITICAL and 3 for UNKNOWN.
+
+Available checks: %s.`, strings.Join(checks, ", ")),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+				return printCheckError(options, err)
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return completeAddresses(options, args), cobra.ShellCompDirectiveNoFileComp
+			}
+			return checks, cobra.ShellCompDirectiveNoFileComp
+		},
+		// The global flags are still validated by the root command, but invalid
+		// ones have to result in an UNKNOWN state just like all other errors.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmd.Root().PersistentPreRunE(cmd, args); err != nil {
+				return printCheckError(options, err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCheck(checkOptions, args)
+		},
+	}
+
+	check.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return printCheckError(options, err)
+	})
+
+	check.Flags().
+		IntVar(&checkOptions.port, "port", buneary.AMQPDefaultPort, "the port checked by port-listener")
+	check.Flags().
+		IntVar(&checkOptions.within, "within", 4, "the time span checked by certificate-expiration")
+	check.Flags().
+		StringVar(&checkOptions.withinUnit, "within-unit", "weeks", "the unit of --within: days, weeks, months or years")
+	check.Flags().
+		StringVar(&checkOptions.queue, "queue", "", "a queue to check against the thresholds")
+	check.Flags().
+		IntVar(&checkOptions.maxReady, "max-ready", -1, "the ready messages in --queue above which the check is critical")
+	check.Flags().
+		IntVar(&checkOptions.warnReady, "warn-ready", -1, "the ready messages in --queue above which the check warns")
+	check.Flags().
+		IntVar(&checkOptions.minConsumers, "max-consumers-min", -1, "the consumers of --queue below which the check is critical")
+
+	_ = check.RegisterFlagCompletionFunc("queue", completeFlag(options, completeQueueNames))
+
+	return check
+}
+
+// runCheck runs all health checks passed as arguments as well as the queue threshold
+// checks and prints a summary in the form `OK - alarms: ok`. If neither a health check
+// nor a queue has been specified, the alarms check will be run.
+//
+// If the overall state isn't OK, an exitError with the corresponding exit code will be
+// returned. Since the summary has already been printed, the error carries no message.
+// Errors preventing the checks from running result in the UNKNOWN state.
+func runCheck(options *checkOptions, args []string) error {
+	var (
+		address = args[0]
+		checks  = args[1:]
+	)
+
+	if len(checks) == 0 && options.queue == "" {
+		checks = []string{string(buneary.AlarmsCheck)}
+	}
+
+	state := checkOK
+	var summaries []string
+	var perfData []string
+
+	report := func(s int, summary string) {
+		if checkSeverities[s] > checkSeverities[state] {
+			state = s
+		}
+		summaries = append(summaries, summary)
+	}
+
+	for _, check := range checks {
+		if !isHealthCheck(check) {
+			report(checkUnknown, fmt.Sprintf("%s: unsupported check", check))
+		}
+	}
+
+	if state != checkOK {
+		return printCheckSummary(options.globalOptions, state, summaries, perfData)
+	}
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return printCheckError(options.globalOptions, err)
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	for _, check := range checks {
+		result, err := provider.RunHealthCheck(options.ctx, buneary.HealthCheck{
+			Type:       buneary.HealthCheckType(check),
+			Port:       options.port,
+			Within:     options.within,
+			WithinUnit: options.withinUnit,
+		})
+
+		switch {
+		case err != nil:
+			report(checkUnknown, fmt.Sprintf("%s: %s", check, err))
+		case !result.Passed:
+			report(checkCritical, fmt.Sprintf("%s: %s", check, result.Reason))
+		default:
+			report(checkOK, fmt.Sprintf("%s: ok", check))
+		}
+	}
+
+	if options.queue != "" {
+		queues, err := provider.GetQueues(options.ctx, func(queue buneary.Queue) bool {
+			return queue.Name == options.queue
+		})
+
+		switch {
+		case err != nil:
+			report(checkUnknown, fmt.Sprintf("queue %s: %s", options.queue, err))
+		case len(queues) == 0:
+			report(checkCritical, fmt.Sprintf("queue %s: not found", options.queue))
+		default:
+			queue := queues[0]
+			queueState := checkOK
+
+			if options.warnReady >= 0 && queue.MessagesReady > options.warnReady {
+				queueState = checkWarning
+			}
+			if options.maxReady >= 0 && queue.MessagesReady > options.maxReady {
+				queueState = checkCritical
+			}
+			if options.minConsumers >= 0 && queue.Consumers < options.minConsumers {
+				queueState = checkCritical
+			}
+
+			report(queueState, fmt.Sprintf("queue %s: %d ready, %d consumers",
+				queue.Name, queue.MessagesReady, queue.Consumers))
+
+			perfData = append(perfData,
+				fmt.Sprintf("ready=%d", queue.MessagesReady),
+				fmt.Sprintf("consumers=%d", queue.Consumers),
+			)
+		}
+	}
+
+	return printCheckSummary(options.globalOptions, state, summaries, perfData)
+}
+
+// printCheckSummary prints the one-line check summary, optionally followed by Nagios
+// performance data. It returns an exitError for all states other than OK.
+func printCheckSummary(options *globalOptions, state int, summaries, perfData []string) error {
+	output := fmt.Sprintf("%s - %s", checkStates[state], strings.Join(summaries, "; "))
+
+	if len(perfData) > 0 {
+		output += " | " + strings.Join(perfData, " ")
+	}
+
+	_, _ = options.out.WriteString(output + "\n")
+
+	if state != checkOK {
+		return &exitError{code: state}
+	}
+
+	return nil
+}
+
+// printCheckError prints the UNKNOWN check summary for an error that prevented the
+// checks from running, e.g. an invalid flag or missing credentials.
+func printCheckError(options *globalOptions, err error) error {
+	return printCheckSummary(options, checkUnknown, []string{err.Error()}, nil)
+}
+
+// isHealthCheck determines whether the given name denotes a supported health check.
+func isHealthCheck(name string) bool {
+	for _, check := range buneary.HealthChecks {
+		if string(check) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteCommand creates the `buneary delete` command without any functionality.
+func deleteCommand(options *globalOptions) *cobra.Command {
+	delete := &cobra.Command{
+		Use:   "delete <COMMAND>",
+		Short: "Delete a resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	delete.AddCommand(deleteExchangeCommand(options))
+	delete.AddCommand(deleteQueueCommand(options))
+	delete.AddCommand(deleteShovelCommand(options))
+	delete.AddCommand(deleteFederationUpstreamCommand(options))
+
+	return delete
+}
+
+// deleteExchangeCommand creates the `buneary delete exchange` command, making sure
+// that exactly two arguments are passed.
+func deleteExchangeCommand(options *globalOptions) *cobra.Command {
+	deleteExchange := &cobra.Command{
+		Use:               "exchange <ADDRESS> <NAME>",
+		Short:             "Delete an exchange",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeExchangeNames),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteExchange(options, args)
+		},
+	}
+
+	return deleteExchange
+}
+
+// runDeleteExchange deletes an exchange by reading the command line data, setting the
+// configuration and calling the DeleteExchange function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+func runDeleteExchange(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	exchange := buneary.Exchange{
+		Name: name,
+	}
+
+	if err := provider.DeleteExchange(options.ctx, exchange); err != nil {
+		return err
+	}
+
+	printSuccess(options, "exchange deleted successfully\n")
+
+	return nil
+}
+
+// deleteQueueCommand creates the `buneary delete queue` command, making sure
+// that exactly two arguments are passed.
+func deleteQueueCommand(options *globalOptions) *cobra.Command {
+	deleteQueue := &cobra.Command{
+		Use:               "queue <ADDRESS> <NAME>",
+		Short:             "Delete a queue",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeQueueNames),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteQueue(options, args)
+		},
+	}
+
+	return deleteQueue
+}
+
+// runDeleteQueue deletes a queue by reading the command line data, setting the
+// configuration and calling the DeleteQueue function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+func runDeleteQueue(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	queue := buneary.Queue{
+		Name: name,
+	}
+
+	if err := provider.DeleteQueue(options.ctx, queue); err != nil {
+		return err
+	}
+
+	printSuccess(options, "queue deleted successfully\n")
+
+	return nil
+}
+
+// versionCommand creates the `buneary version` command for printing release
+// information. This data is injected by the CI pipeline.
+func versionCommand(options *globalOptions) *cobra.Command {
+	version := &cobra.Command{
+		Use:   "version",
+		Short: "Print version information",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output := fmt.Sprintf("buneary version %s\n", version)
+			_, _ = options.out.WriteString(output)
+
+			return nil
+		},
+	}
+
+	return version
+}
+
+// newConfig creates the configuration for the server at the given address, reading in
+// the credentials if required. If an OAuth2 token has been configured, the token is
+// used and there's no need for a password. Within a shell session, the session's
+// configuration is used instead and the address is ignored.
+//
+// If a client certificate has been configured, AMQP connections are authenticated
+// using the certificate and the credentials aren't prompted for. Those that can be
+// obtained otherwise are still used for the HTTP API.
+func newConfig(options *globalOptions, address string) (*buneary.RabbitMQConfig, error) {
+	if options.session != nil {
+		config := *options.session.config
+		if options.vhost != "" {
+			config.VirtualHost = options.vhost
+		}
+		if options.timeout != 0 {
+			config.Timeout = options.timeout
+		}
+		if options.dryRun {
+			config.DryRun = true
+			config.DryRunOutput = os.Stdout
+		}
+		return &config, nil
+	}
+
+	tokenSource, err := lookUpTokenSource(options)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var user, password string
+
+	switch {
+	case tokenSource != nil:
+		user = lookUpUser(options)
+	case options.cert != "":
+		user, password, err = lookUpCredentials(options, address)
+	default:
+		user, password, err = getOrReadInCredentials(options, address)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	config := serverConfig(options, address, user, password)
+	config.TokenSource = tokenSource
+	config.TLSConfig = tlsConfig
+	config.ExternalAuth = options.cert != ""
+
+	return config, nil
+}
+
+// serverConfig creates the configuration for the server at the given address using
+// the given credentials and the global options.
+func serverConfig(options *globalOptions, address, user, password string) *buneary.RabbitMQConfig {
+	config := &buneary.RabbitMQConfig{
+		Address:     address,
+		User:        user,
+		Password:    password,
+		Timeout:     options.timeout,
+		VirtualHost: options.vhost,
+		DryRun:      options.dryRun,
+	}
+
+	if options.dryRun {
+		config.DryRunOutput = os.Stdout
+	}
+
+	return config
+}
+
+// newProvider creates a Provider for the server at the given address. See newConfig
+// for details. Within a shell session, the session's Provider is returned so that all
+// commands share the same connections, unless another virtual host or dry-run mode
+// has been chosen.
+func newProvider(options *globalOptions, address string) (buneary.Provider, error) {
+	if s := options.session; s != nil && (options.vhost == "" || options.vhost == s.config.VirtualHost) && (!options.dryRun || s.config.DryRun) {
+		return sharedProvider{Provider: s.provider}, nil
+	}
+
+	config, err := newConfig(options, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return buneary.NewProvider(config), nil
+}
+
+// confirm asks the user to confirm the given message or question by answering with
+// "y" for yes or "n" for no. Returns true if the user confirmed the message, and an
+// error if the user hit Ctrl-C instead of answering.
+func confirm(options *globalOptions, message string) (bool, error) {
+	output := fmt.Sprintf("%s [y/N] ", message)
+
+	_, _ = options.out.WriteString(output)
+
+	answer, err := readLine(options.ctx)
+	if err != nil {
+		return false, err
+	}
+
+	_, _ = options.out.WriteString("\n")
+
+	return answer == "y" || answer == "yes", nil
+}
+
+// stdin is shared by all calls to readLine, so that no buffered input gets lost.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readLine reads a single line from stdin and returns it without surrounding white
+// space. It returns the context's error if the context is done before.
+func readLine(ctx context.Context) (string, error) {
+	if stdin.Buffered() == 0 {
+		if err := waitForStdin(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	line, _ := stdin.ReadString('\n')
+
+	return strings.TrimSpace(line), nil
+}
+
+// stdinPollInterval is the interval at which waitForStdin checks the context.
+const stdinPollInterval = 50 * time.Millisecond
+
+// waitForStdin blocks until stdin is readable or the context is done. Waiting for
+// input before reading it, instead of reading it in a goroutine, makes sure that no
+// read is left pending once the context is done. Such a read would swallow the input
+// meant for the next reader, e.g. the prompt of a shell session.
+func waitForStdin(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ready, err := pollStdin(stdinPollInterval)
+		if err != nil {
+			return fmt.Errorf("waiting for stdin: %w", err)
+		}
+		if ready {
+			return nil
+		}
+	}
+}
+
+// printSuccess prints the given success message unless the command is running in
+// dry-run mode, where nothing has actually been changed.
+func printSuccess(options *globalOptions, message string) {
+	if options.dryRun {
+		return
+	}
+	_, _ = options.out.WriteString(message)
+}
+
+// countSet returns the number of the given conditions that are true. It is used for
+// checking mutually exclusive flags.
+func countSet(conditions ...bool) int {
+	n := 0
+	for _, condition := range conditions {
+		if condition {
+			n++
+		}
+	}
+	return n
+}
+
+// boolToString returns "yes" if the given bool is true and "no" if it is false.
+func boolToString(source bool) string {
+	if source {
+		return "yes"
+	}
+	return "no"
+}
+
+// parseHeaders parses message headers in the form key1=val1,key2=val2 and stores them
+// in the given map. If the headers do not adhere to this syntax, an error is returned.
+// In case the same key exists multiple times, the last one wins.
+func parseHeaders(source string, headers map[string]interface{}) error {
+	if source == "" {
+		return nil
+	}
+
+	for _, header := range strings.Split(source, ",") {
+		tokens := strings.Split(strings.TrimSpace(header), "=")
+
+		if len(tokens) != 2 {
+			return errors.New("expected header in form key=value")
+		}
+
+		key := tokens[0]
+		value := tokens[1]
+
+		headers[key] = value
+	}
+
+	return nil
+}
+
+// propertiesToRows returns all non-empty properties and headers of a message as key-
+// value rows, ready to be appended to a table.
+func propertiesToRows(message buneary.Message) [][]string {
+	properties := message.Properties
+
+	rows := [][]string{
+		{"Exchange", message.Target.Name},
+		{"Routing Key", message.RoutingKey},
+		{"Content Type", properties.ContentType},
+		{"Content Encoding", properties.ContentEncoding},
+		{"Correlation ID", properties.CorrelationID},
+		{"Reply To", properties.ReplyTo},
+		{"Message ID", properties.MessageID},
+		{"Type", properties.Type},
+		{"App ID", properties.AppID},
+		{"User ID", properties.UserID},
+		{"Expiration", properties.Expiration},
+	}
+
+	if properties.DeliveryMode != 0 {
+		rows = append(rows, []string{"Delivery Mode", strconv.Itoa(int(properties.DeliveryMode))})
+	}
+	if properties.Priority != 0 {
+		rows = append(rows, []string{"Priority", strconv.Itoa(int(properties.Priority))})
+	}
+	if !properties.Timestamp.IsZero() {
+		rows = append(rows, []string{"Timestamp", properties.Timestamp.Format(time.RFC3339)})
+	}
+
+	keys := make([]string, 0, len(message.Headers))
+	for key := range message.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		rows = append(rows, []string{"Header " + key, fmt.Sprint(message.Headers[key])})
+	}
+
+	var nonEmpty [][]string
+
+	for _, row := range rows {
+		if row[1] != "" {
+			nonEmpty = append(nonEmpty, row)
+		}
+	}
+
+	return nonEmpty
+}
+
+// isBodyFormat determines whether the given name denotes a supported body format.
+func isBodyFormat(name string) bool {
+	switch name {
+	case bodyText, bodyHex, bodyBase64, bodyHexdump, bodyJSONPretty:
+		return true
+	}
+	return false
+}
+
+// formatBody formats a message body for printing it to the terminal. Bodies that
+// can't be formatted as requested, e.g. invalid JSON with json-pretty, are printed
+// as text instead.
+//
+// The text format escapes all control characters except for newlines and tabs as
+// well as invalid UTF-8 sequences, so that binary bodies can't mess up the terminal.
+func formatBody(body []byte, format string) string {
+	switch format {
+	case bodyHex:
+		return hex.EncodeToString(body)
+	case bodyBase64:
+		return base64.StdEncoding.EncodeToString(body)
+	case bodyHexdump:
+		return strings.TrimSuffix(hex.Dump(body), "\n")
+	case bodyJSONPretty:
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err == nil {
+			return indented.String()
+		}
+	}
+
+	var b strings.Builder
+
+	for len(body) > 0 {
+		r, size := utf8.DecodeRune(body)
+
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			b.WriteString(fmt.Sprintf("\\x%02x", body[0]))
+		case r == '\n' || r == '\t':
+			b.WriteRune(r)
+		case unicode.IsControl(r):
+			b.WriteString(fmt.Sprintf("\\x%02x", r))
+		default:
+			b.WriteRune(r)
+		}
+
+		body = body[size:]
+	}
+
+	return b.String()
+}
+
+// rateToString formats a message rate in messages per second with one decimal.
+func rateToString(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', 1, 64)
+}
+
+// bytesToString formats the given number of bytes using binary units, e.g. 1.5 GiB.
+func bytesToString(bytes int) string {
+	const unit = 1024
+
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := unit, 0
+
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// printJSON writes the given value as indented JSON to the configured output. It is
+// used by all commands supporting the --output json flag.
+func printJSON(options *globalOptions, v interface{}) error {
+	output, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling output: %w", err)
+	}
+
+	_, _ = options.out.WriteString(string(output) + "\n")
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestRootCommand_Args(t *testing.T) {
+	tests := [][]string{
+		{"create", "exchange", "localhost", "my-exchange"},
+		{"create", "queue", "localhost", "my-queue"},
+		{"delete", "exchange", "localhost"},
+		{"delete", "queue", "localhost"},
+		{"get", "messages", "localhost"},
+		{"publish", "localhost", "my-exchange"},
+		{"dump", "localhost", "--file", "-"},
+		{"restore", "localhost"},
+	}
+
+	// Missing arguments have to be reported before the command runs, which would
+	// otherwise access them and panic.
+	for _, args := range tests {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			root := rootCommand(nil)
+			root.SetArgs(args)
+			root.SetOut(ioutil.Discard)
+			root.SetErr(ioutil.Discard)
+
+			err := root.ExecuteContext(context.Background())
+			if err == nil || !strings.Contains(err.Error(), "arg(s)") {
+				t.Errorf("expected an error for the missing arguments, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// completionCacheTTL is the time for which resource names fetched from the server
+	// are re-used for completion. It avoids a request for each hit of the Tab key.
+	completionCacheTTL = time.Minute
+
+	// completionTimeout is the timeout for fetching resource names if no --timeout has
+	// been set. A hung server must not block the shell.
+	completionTimeout = 5 * time.Second
+)
+
+// completionShells lists all shells a completion script can be generated for.
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completionCommand creates the `buneary completion` command, which prints the shell
+// completion script for the given shell.
+func completionCommand(options *globalOptions) *cobra.Command {
+	completion := &cobra.Command{
+		Use:   "completion <SHELL>",
+		Short: "Print the shell completion script for bash, zsh, fish or powershell",
+		Long: `Print the shell completion script for bash, zsh, fish or powershell.
+
+To load the completions in the current bash session, run:
+
+  $ source <(buneary completion bash)
+
+Exchange and queue names are completed by querying the server given by the address
+argument. This requires credentials that don't have to be prompted for, e.g. from
+flags, environment variables or a credential helper.`,
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: completionShells,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompletion(options, cmd.Root(), args)
+		},
+	}
+
+	return completion
+}
+
+// runCompletion generates the completion script for the shell passed as argument.
+func runCompletion(options *globalOptions, root *cobra.Command, args []string) error {
+	var (
+		shell = args[0]
+		buf   bytes.Buffer
+		err   error
+	)
+
+	switch shell {
+	case "bash":
+		err = root.GenBashCompletion(&buf)
+	case "zsh":
+		err = root.GenZshCompletion(&buf)
+	case "fish":
+		err = root.GenFishCompletion(&buf, true)
+	case "powershell":
+		err = root.GenPowerShellCompletion(&buf)
+	}
+
+	if err != nil {
+		return fmt.Errorf("generating %s completion: %w", shell, err)
+	}
+
+	_, _ = options.out.WriteString(buf.String())
+
+	return nil
+}
+
+// argCompleter completes a single positional argument. It receives the arguments that
+// have been typed in so far, where args[0] usually is the server address.
+type argCompleter func(options *globalOptions, args []string) []string
+
+// completeArgs returns a ValidArgsFunction that completes each positional argument
+// using the argCompleter at the same index. Arguments without an argCompleter or a
+// nil argCompleter aren't completed at all.
+func completeArgs(options *globalOptions, completers ...argCompleter) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= len(completers) || completers[len(args)] == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completers[len(args)](options, args), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeFlag returns a completion function for a flag using the given argCompleter.
+// The flag value can only be completed once the address has been typed in.
+func completeFlag(options *globalOptions, completer argCompleter) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completer(options, args), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeAddresses completes all server addresses that resource names have been
+// fetched from before.
+func completeAddresses(_ *globalOptions, _ []string) []string {
+	cache := readCompletionCache()
+	seen := make(map[string]bool)
+
+	var addresses []string
+
+	for _, entry := range cache {
+		if !seen[entry.Address] {
+			seen[entry.Address] = true
+			addresses = append(addresses, entry.Address)
+		}
+	}
+
+	sort.Strings(addresses)
+
+	return addresses
+}
+
+// completeExchangeNames completes the names of all exchanges on the server.
+func completeExchangeNames(options *globalOptions, args []string) []string {
+	return fetchNames(options, args[0], "exchanges", func(ctx context.Context, provider buneary.Provider) ([]string, error) {
+		exchanges, err := provider.GetExchanges(ctx, func(exchange buneary.Exchange) bool {
+			// The default exchange can't be referenced by its empty name.
+			return exchange.Name != ""
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, len(exchanges))
+		for i, exchange := range exchanges {
+			names[i] = exchange.Name
+		}
+
+		return names, nil
+	})
+}
+
+// completeQueueNames completes the names of all queues on the server.
+func completeQueueNames(options *globalOptions, args []string) []string {
+	return fetchNames(options, args[0], "queues", func(ctx context.Context, provider buneary.Provider) ([]string, error) {
+		queues, err := provider.GetQueues(ctx, func(_ buneary.Queue) bool {
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, len(queues))
+		for i, queue := range queues {
+			names[i] = queue.Name
+		}
+
+		return names, nil
+	})
+}
+
+// completeBindingTargets completes the names of all queues, or the names of all
+// exchanges if toExchange is set. toExchange is read once the completion runs.
+func completeBindingTargets(toExchange *bool) argCompleter {
+	return func(options *globalOptions, args []string) []string {
+		if *toExchange {
+			return completeExchangeNames(options, args)
+		}
+		return completeQueueNames(options, args)
+	}
+}
+
+// completeTargetNames completes the names of all queues and exchanges, which are the
+// possible targets of a binding.
+func completeTargetNames(options *globalOptions, args []string) []string {
+	return append(completeQueueNames(options, args), completeExchangeNames(options, args)...)
+}
+
+// completeExchangeTypes completes all exchange types.
+func completeExchangeTypes(_ *globalOptions, _ []string) []string {
+	return []string{
+		string(buneary.Direct),
+		string(buneary.Headers),
+		string(buneary.Fanout),
+		string(buneary.Topic),
+	}
+}
+
+// completeQueueTypes completes all queue types.
+func completeQueueTypes(_ *globalOptions, _ []string) []string {
+	return []string{
+		string(buneary.Classic),
+		string(buneary.Quorum),
+	}
+}
+
+// completionCacheEntry holds the resource names of a particular kind fetched from a
+// virtual host on the server at the given address.
+type completionCacheEntry struct {
+	Address     string    `json:"address"`
+	VirtualHost string    `json:"vhost"`
+	Kind        string    `json:"kind"`
+	Names       []string  `json:"names"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// fetchNames returns the resource names of the given kind from the completion cache,
+// or fetches them from the server if they haven't been cached or have expired.
+//
+// Completion must not prompt for credentials, so nothing is completed if they can't be
+// obtained without a prompt. All errors are ignored as well, since they can't be shown.
+func fetchNames(options *globalOptions, address, kind string, fetch func(ctx context.Context, provider buneary.Provider) ([]string, error)) []string {
+	vhost := options.vhost
+	if vhost == "" && options.session != nil {
+		vhost = options.session.config.VirtualHost
+	}
+
+	cache := readCompletionCache()
+	key := address + " " + vhost + " " + kind
+
+	if entry, ok := cache[key]; ok && time.Since(entry.FetchedAt) < completionCacheTTL {
+		return entry.Names
+	}
+
+	timeout := options.timeout
+	if timeout == 0 {
+		timeout = completionTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(options.ctx, timeout)
+	defer cancel()
+
+	provider, err := completionProvider(options, address)
+	if err != nil {
+		return nil
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	names, err := fetch(ctx, provider)
+	if err != nil {
+		return nil
+	}
+
+	sort.Strings(names)
+
+	cache[key] = completionCacheEntry{
+		Address:     address,
+		VirtualHost: vhost,
+		Kind:        kind,
+		Names:       names,
+		FetchedAt:   time.Now(),
+	}
+
+	writeCompletionCache(cache)
+
+	return names
+}
+
+// completionProvider creates a Provider for fetching resource names without prompting
+// for credentials. It returns an error if the credentials are incomplete.
+//
+// The credential helper isn't asked either, since it might prompt the user for a
+// master password on the terminal while Tab is being pressed.
+func completionProvider(options *globalOptions, address string) (buneary.Provider, error) {
+	if options.session != nil {
+		return newProvider(options, address)
+	}
+
+	tokenSource, err := lookUpTokenSource(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenSource != nil {
+		config := serverConfig(options, address, lookUpUser(options), "")
+		config.TokenSource = tokenSource
+		return buneary.NewProvider(config), nil
+	}
+
+	// Stdin is attached to the terminal during completion.
+	if options.passwordStdin {
+		return nil, errors.New("can't read the password from stdin")
+	}
+
+	user := lookUpUser(options)
+
+	password, err := lookUpPassword(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == "" || password == "" {
+		return nil, errors.New("missing credentials")
+	}
+
+	return buneary.NewProvider(serverConfig(options, address, user, password)), nil
+}
+
+// completionCachePath returns the path of the completion cache, which is stored as
+// buneary/completion.json inside the user's cache directory.
+func completionCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "buneary", "completion.json"), nil
+}
+
+// readCompletionCache reads the completion cache. A missing or broken cache yields an
+// empty cache.
+func readCompletionCache() map[string]completionCacheEntry {
+	cache := make(map[string]completionCacheEntry)
+
+	path, err := completionCachePath()
+	if err != nil {
+		return cache
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	_ = json.Unmarshal(data, &cache)
+
+	return cache
+}
+
+// writeCompletionCache writes the completion cache. Failures are ignored, in which
+// case the names will simply be fetched again next time.
+func writeCompletionCache(cache map[string]completionCacheEntry) {
+	path, err := completionCachePath()
+	if err != nil {
+		return
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	_ = ioutil.WriteFile(path, data, 0600)
+}
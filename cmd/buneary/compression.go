@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// The content encodings supported for compressing and decompressing message bodies.
+// Bodies can be decompressed using any of them, but only compressed using
+// encodingGzip and encodingZstd.
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+	encodingZstd    = "zstd"
+)
+
+// compressBody compresses the body using the given content encoding, which is
+// either encodingGzip or encodingZstd.
+func compressBody(body []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch encoding {
+	case encodingGzip:
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(body); err != nil {
+			return nil, fmt.Errorf("compressing body: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("compressing body: %w", err)
+		}
+	case encodingZstd:
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("compressing body: %w", err)
+		}
+		buf.Write(encoder.EncodeAll(body, nil))
+		_ = encoder.Close()
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", encoding)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// maxDecompressedSize is the maximum size of a decompressed body. Small bodies may be
+// decompressed into huge ones, so larger bodies are refused instead of exhausting
+// the memory.
+var maxDecompressedSize int64 = 64 << 20
+
+// decompressBody decompresses the body according to the given content encoding.
+// Bodies with an empty or an unknown encoding are returned as they are. An error is
+// returned if the decompressed body exceeds maxDecompressedSize.
+//
+// Following HTTP, the deflate encoding refers to the zlib format.
+func decompressBody(body []byte, encoding string) ([]byte, error) {
+	var (
+		decompressed []byte
+		err          error
+	)
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case encodingGzip:
+		var reader *gzip.Reader
+		if reader, err = gzip.NewReader(bytes.NewReader(body)); err == nil {
+			decompressed, err = readDecompressed(reader)
+		}
+	case encodingDeflate:
+		var reader io.ReadCloser
+		if reader, err = zlib.NewReader(bytes.NewReader(body)); err == nil {
+			decompressed, err = readDecompressed(reader)
+		}
+	case encodingZstd:
+		var decoder *zstd.Decoder
+		if decoder, err = zstd.NewReader(bytes.NewReader(body), zstd.WithDecoderConcurrency(1)); err == nil {
+			decompressed, err = readDecompressed(decoder)
+			decoder.Close()
+		}
+	default:
+		return body, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decompressing %s body: %w", encoding, err)
+	}
+
+	return decompressed, nil
+}
+
+// readDecompressed reads the decompressed body from the given reader, but not more
+// than maxDecompressedSize bytes.
+func readDecompressed(reader io.Reader) ([]byte, error) {
+	decompressed, err := ioutil.ReadAll(io.LimitReader(reader, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(decompressed)) > maxDecompressedSize {
+		return nil, fmt.Errorf("decompressed body exceeds %d bytes", maxDecompressedSize)
+	}
+
+	return decompressed, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"strings"
+	"testing"
+)
+
+// compressTestBody compresses the body using the given encoding. Unlike compressBody,
+// it supports encodingDeflate, which buneary only decompresses.
+func compressTestBody(t *testing.T, body []byte, encoding string) []byte {
+	t.Helper()
+
+	if encoding != encodingDeflate {
+		compressed, err := compressBody(body, encoding)
+		if err != nil {
+			t.Fatalf("compressing body: %v", err)
+		}
+		return compressed
+	}
+
+	var buf bytes.Buffer
+
+	writer := zlib.NewWriter(&buf)
+	if _, err := writer.Write(body); err != nil {
+		t.Fatalf("compressing body: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("compressing body: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecompressBody_RoundTrip(t *testing.T) {
+	bodies := map[string][]byte{
+		"empty":  {},
+		"text":   []byte(`{"id": 42, "customer": "acme"}`),
+		"binary": {0, 1, 2, 0xfe, 0xff},
+		"large":  bytes.Repeat([]byte("buneary "), 100000),
+	}
+
+	for _, encoding := range []string{encodingGzip, encodingDeflate, encodingZstd} {
+		for name, body := range bodies {
+			t.Run(encoding+" "+name, func(t *testing.T) {
+				compressed := compressTestBody(t, body, encoding)
+
+				decompressed, err := decompressBody(compressed, encoding)
+				if err != nil {
+					t.Fatalf("decompressing body: %v", err)
+				}
+
+				if !bytes.Equal(decompressed, body) {
+					t.Errorf("expected %d decompressed bytes to equal the body, got %d bytes", len(decompressed), len(body))
+				}
+			})
+		}
+	}
+}
+
+func TestDecompressBody_Encoding(t *testing.T) {
+	body := []byte("hello")
+
+	// The encoding is matched case-insensitively and ignoring surrounding spaces.
+	decompressed, err := decompressBody(compressTestBody(t, body, encodingGzip), " GZIP ")
+	if err != nil || !bytes.Equal(decompressed, body) {
+		t.Errorf("expected %q, got %q and %v", body, decompressed, err)
+	}
+
+	for _, encoding := range []string{"", "identity", "br"} {
+		decompressed, err := decompressBody(body, encoding)
+		if err != nil || !bytes.Equal(decompressed, body) {
+			t.Errorf("expected body with encoding %q to be returned as it is, got %q and %v", encoding, decompressed, err)
+		}
+	}
+
+	if _, err := compressBody(body, encodingDeflate); err == nil {
+		t.Errorf("expected an error compressing with %s", encodingDeflate)
+	}
+}
+
+func TestDecompressBody_Invalid(t *testing.T) {
+	for _, encoding := range []string{encodingGzip, encodingDeflate, encodingZstd} {
+		if _, err := decompressBody([]byte("not compressed"), encoding); err == nil {
+			t.Errorf("expected an error decompressing an invalid %s body", encoding)
+		}
+	}
+}
+
+func TestDecompressBody_Limit(t *testing.T) {
+	defer func(size int64) {
+		maxDecompressedSize = size
+	}(maxDecompressedSize)
+
+	maxDecompressedSize = 1024
+
+	for _, encoding := range []string{encodingGzip, encodingDeflate, encodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			body := bytes.Repeat([]byte{'a'}, int(maxDecompressedSize))
+
+			if _, err := decompressBody(compressTestBody(t, body, encoding), encoding); err != nil {
+				t.Errorf("expected a body of the maximum size to be decompressed, got %v", err)
+			}
+
+			body = append(body, 'a')
+
+			_, err := decompressBody(compressTestBody(t, body, encoding), encoding)
+			if err == nil || !strings.Contains(err.Error(), "exceeds") {
+				t.Errorf("expected an error for a body exceeding the maximum size, got %v", err)
+			}
+		})
+	}
+}
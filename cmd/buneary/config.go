@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// config represents the buneary configuration file. The configuration file is
+// optional: If it doesn't exist at the default location, an empty configuration
+// is used instead.
+type config struct {
+	Schemas []schemaMapping `json:"schemas"`
+}
+
+// schemaMapping maps messages published to an exchange with a matching routing key
+// to a JSON Schema file. The routing key pattern supports the wildcards of topic
+// exchanges: * matches exactly one word and # matches zero or more words.
+type schemaMapping struct {
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routing_key"`
+	Schema     string `json:"schema"`
+}
+
+// defaultConfigPath returns the default location of the configuration file, which
+// is buneary/config.json inside the user's configuration directory.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "buneary", "config.json")
+}
+
+// loadConfig reads the configuration file at the given path. If no path is given,
+// the default path is used, and a missing file results in an empty configuration.
+//
+// Relative schema paths are resolved against the directory of the configuration
+// file so that the configuration doesn't depend on the working directory.
+func loadConfig(path string) (*config, error) {
+	explicit := path != ""
+
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	var c config
+
+	if path == "" {
+		return &c, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return &c, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	for i, mapping := range c.Schemas {
+		if mapping.Schema != "" && !filepath.IsAbs(mapping.Schema) {
+			c.Schemas[i].Schema = filepath.Join(filepath.Dir(path), mapping.Schema)
+		}
+	}
+
+	return &c, nil
+}
+
+// schemaFor returns the schema file of the first mapping that matches the given
+// exchange and routing key, or an empty string if there is no such mapping.
+func (c *config) schemaFor(exchange, routingKey string) string {
+	for _, mapping := range c.Schemas {
+		if mapping.Exchange == exchange && matchRoutingKey(mapping.RoutingKey, routingKey) {
+			return mapping.Schema
+		}
+	}
+	return ""
+}
+
+// matchRoutingKey reports whether the routing key matches the given topic pattern.
+// An empty pattern is treated like # and matches any routing key. Like in RabbitMQ,
+// an empty routing key consists of zero words, whereas empty words between dots
+// are regular words.
+func matchRoutingKey(pattern, routingKey string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	var words []string
+
+	if routingKey != "" {
+		words = strings.Split(routingKey, ".")
+	}
+
+	return matchWords(strings.Split(pattern, "."), words)
+}
+
+// matchWords matches the words of a routing key against the words of a pattern,
+// trying all possible expansions of the # wildcard.
+func matchWords(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if matchWords(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && matchWords(pattern[1:], words[1:])
+	default:
+		return len(words) > 0 && pattern[0] == words[0] && matchWords(pattern[1:], words[1:])
+	}
+}
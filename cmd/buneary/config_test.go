@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchRoutingKey(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		routingKey string
+		want       bool
+	}{
+		// Plain words.
+		{pattern: "orders.created", routingKey: "orders.created", want: true},
+		{pattern: "orders.created", routingKey: "orders.deleted", want: false},
+		{pattern: "orders", routingKey: "orders.created", want: false},
+		{pattern: "orders.created", routingKey: "orders", want: false},
+
+		// * matches exactly one word.
+		{pattern: "orders.*", routingKey: "orders.created", want: true},
+		{pattern: "orders.*", routingKey: "orders", want: false},
+		{pattern: "orders.*", routingKey: "orders.eu.created", want: false},
+		{pattern: "*.created", routingKey: "orders.created", want: true},
+		{pattern: "*.*", routingKey: "orders.created", want: true},
+		{pattern: "*", routingKey: "orders", want: true},
+		{pattern: "*", routingKey: "orders.created", want: false},
+
+		// # matches zero or more words.
+		{pattern: "#", routingKey: "orders.eu.created", want: true},
+		{pattern: "orders.#", routingKey: "orders", want: true},
+		{pattern: "orders.#", routingKey: "orders.eu.created", want: true},
+		{pattern: "#.created", routingKey: "created", want: true},
+		{pattern: "#.created", routingKey: "orders.eu.created", want: true},
+		{pattern: "#.created", routingKey: "orders.created.late", want: false},
+		{pattern: "orders.#.created", routingKey: "orders.created", want: true},
+		{pattern: "orders.#.created", routingKey: "orders.eu.west.created", want: true},
+		{pattern: "#.#", routingKey: "orders", want: true},
+		{pattern: "#.*", routingKey: "orders.eu", want: true},
+		{pattern: "*.#", routingKey: "orders", want: true},
+		{pattern: "#.*.#", routingKey: "orders.eu.created", want: true},
+		{pattern: "orders.#.*", routingKey: "orders", want: false},
+
+		// Trailing wildcards.
+		{pattern: "orders.eu.#", routingKey: "orders.eu", want: true},
+		{pattern: "orders.eu.#", routingKey: "orders.us", want: false},
+		{pattern: "orders.eu.*", routingKey: "orders.eu", want: false},
+
+		// Empty routing keys consist of zero words, empty segments are words.
+		{pattern: "#", routingKey: "", want: true},
+		{pattern: "*", routingKey: "", want: false},
+		{pattern: "orders.#", routingKey: "", want: false},
+		{pattern: "orders..created", routingKey: "orders..created", want: true},
+		{pattern: "orders.*.created", routingKey: "orders..created", want: true},
+		{pattern: "orders.created", routingKey: "orders..created", want: false},
+		{pattern: "orders.*", routingKey: "orders.", want: true},
+		{pattern: "*.orders", routingKey: ".orders", want: true},
+		{pattern: "orders.#", routingKey: "orders.", want: true},
+
+		// Empty patterns match any routing key.
+		{pattern: "", routingKey: "orders.created", want: true},
+		{pattern: "", routingKey: "", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.routingKey, func(t *testing.T) {
+			if got := matchRoutingKey(test.pattern, test.routingKey); got != test.want {
+				t.Errorf("expected %q matching %q to be %v, got %v", test.pattern, test.routingKey, test.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buneary")
+	if err != nil {
+		t.Fatalf("creating directory: %v", err)
+	}
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	path := filepath.Join(dir, "config.json")
+	content := `{"schemas": [
+		{"exchange": "shop", "routing_key": "orders.#", "schema": "order.json"},
+		{"exchange": "shop", "schema": "/schemas/shop.json"}
+	]}`
+
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loading config file: %v", err)
+	}
+
+	tests := []struct {
+		exchange   string
+		routingKey string
+		want       string
+	}{
+		// Relative schema paths are resolved against the configuration file.
+		{exchange: "shop", routingKey: "orders.created", want: filepath.Join(dir, "order.json")},
+		// The first matching mapping wins.
+		{exchange: "shop", routingKey: "payments.created", want: "/schemas/shop.json"},
+		{exchange: "other", routingKey: "orders.created", want: ""},
+	}
+
+	for _, test := range tests {
+		if schema := c.schemaFor(test.exchange, test.routingKey); schema != test.want {
+			t.Errorf("expected schema %q for %s and %s, got %q", test.want, test.exchange, test.routingKey, schema)
+		}
+	}
+
+	if _, err := loadConfig(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected an error for a missing config file passed explicitly")
+	}
+}
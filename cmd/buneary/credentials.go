@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// The environment variables buneary reads the credentials from if they haven't been
+// provided as flags.
+const (
+	envUser             = "BUNEARY_USER"
+	envPassword         = "BUNEARY_PASSWORD"
+	envCredentialHelper = "BUNEARY_CREDENTIAL_HELPER"
+	envToken            = "BUNEARY_TOKEN"
+	envClientSecret     = "BUNEARY_CLIENT_SECRET"
+)
+
+// getOrReadInCredentials returns the credentials for the server at the given address,
+// prompting the user to type in the ones that can't be obtained otherwise.
+//
+// The user is taken from the --user flag or the BUNEARY_USER environment variable,
+// and the password from --password, --password-file, --password-stdin or the
+// BUNEARY_PASSWORD environment variable. If either of them is still missing, the
+// credential helper is asked. Only the missing credentials are prompted for, so
+// setting --user alone results in a prompt for the password.
+//
+// Reading from stdin is aborted once the user hits Ctrl-C, in which case the terminal
+// state is restored and the context's error is returned.
+func getOrReadInCredentials(options *globalOptions, address string) (string, string, error) {
+	user, password, err := lookUpCredentials(options, address)
+	if err != nil {
+		return "", "", err
+	}
+
+	if user == "" && options.passwordStdin {
+		return "", "", errors.New("--password-stdin requires the user to be set via --user or " + envUser)
+	}
+
+	if user == "" {
+		_, _ = options.out.WriteString("User: ")
+
+		user, err = readLine(options.ctx)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if password == "" {
+		password, err = readPassword(options)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return user, password, nil
+}
+
+// lookUpCredentials returns the credentials for the server at the given address from
+// all sources except for the interactive prompt. Credentials that couldn't be found
+// are returned as empty strings.
+func lookUpCredentials(options *globalOptions, address string) (string, string, error) {
+	user := lookUpUser(options)
+
+	password, err := lookUpPassword(options)
+	if err != nil {
+		return "", "", err
+	}
+
+	if user != "" && password != "" {
+		return user, password, nil
+	}
+
+	helper := options.credentialHelper
+	if helper == "" {
+		helper = os.Getenv(envCredentialHelper)
+	}
+
+	if helper == "" {
+		return user, password, nil
+	}
+
+	helperUser, helperPassword, err := runCredentialHelper(options.ctx, helper, address, user)
+	if err != nil {
+		return "", "", err
+	}
+
+	if user == "" {
+		user = helperUser
+	}
+
+	if password == "" {
+		password = helperPassword
+	}
+
+	return user, password, nil
+}
+
+// lookUpUser returns the user from the --user flag or the BUNEARY_USER environment
+// variable.
+func lookUpUser(options *globalOptions) string {
+	if options.user != "" {
+		return options.user
+	}
+	return os.Getenv(envUser)
+}
+
+// lookUpPassword returns the password from the --password, --password-file or the
+// --password-stdin flag, falling back to the BUNEARY_PASSWORD environment variable.
+// A trailing line break in a file or stdin isn't considered part of the password.
+func lookUpPassword(options *globalOptions) (string, error) {
+	switch {
+	case options.password != "":
+		return options.password, nil
+	case options.passwordFile != "":
+		data, err := ioutil.ReadFile(options.passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("reading password file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case options.passwordStdin:
+		data, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("reading password from stdin: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	return os.Getenv(envPassword), nil
+}
+
+// runCredentialHelper asks an external credential helper for the credentials of the
+// server at the given address, using the protocol of Git credential helpers.
+//
+// The helper is run with `get` as its last argument and reads the protocol, host and
+// the user if known from stdin. It prints the credentials as username=<USER> and
+// password=<PASSWORD> lines. Its stderr is passed through so that it can ask the
+// user for a master password, for instance.
+func runCredentialHelper(ctx context.Context, helper, address, user string) (string, string, error) {
+	words, err := splitWords(helper)
+	if err != nil || len(words) == 0 {
+		return "", "", fmt.Errorf("invalid credential helper: %s", helper)
+	}
+
+	var input bytes.Buffer
+
+	_, _ = fmt.Fprintf(&input, "protocol=http\nhost=%s\n", address)
+	if user != "" {
+		_, _ = fmt.Fprintf(&input, "username=%s\n", user)
+	}
+	input.WriteString("\n")
+
+	var output bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, words[0], append(words[1:], "get")...)
+	cmd.Stdin = &input
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", "", ctx.Err()
+		}
+		return "", "", fmt.Errorf("running credential helper: %w", err)
+	}
+
+	var helperUser, helperPassword string
+
+	scanner := bufio.NewScanner(&output)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+
+		key, value := line, ""
+		if i := strings.Index(line, "="); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+
+		switch key {
+		case "username":
+			helperUser = value
+		case "password":
+			helperPassword = value
+		}
+	}
+
+	return helperUser, helperPassword, nil
+}
+
+// readPassword prompts the user to type in the password without echoing it.
+//
+// The terminal is switched into raw mode, which disables echoing as well as the line
+// editing of the terminal. Hence, the password is read key by key.
+func readPassword(options *globalOptions) (string, error) {
+	_, _ = options.out.WriteString("Password: ")
+
+	fd := int(syscall.Stdin)
+
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("reading password from stdin: %w", err)
+	}
+
+	password, err := readPasswordKeys(options.ctx)
+
+	_ = terminal.Restore(fd, state)
+	_, _ = options.out.WriteString("\n")
+
+	return password, err
+}
+
+// readPasswordKeys reads the keys typed in by the user until Enter is hit. Raw mode
+// disables signals, so Ctrl-C is handled here and results in context.Canceled.
+func readPasswordKeys(ctx context.Context) (string, error) {
+	var password []byte
+
+	key := make([]byte, 1)
+
+	for {
+		if err := waitForStdin(ctx); err != nil {
+			return "", err
+		}
+
+		if _, err := os.Stdin.Read(key); err != nil {
+			return "", fmt.Errorf("reading password from stdin: %w", err)
+		}
+
+		switch key[0] {
+		case '\r', '\n':
+			return string(password), nil
+		case 3: // Ctrl-C
+			return "", context.Canceled
+		case '\b', 127:
+			if len(password) > 0 {
+				password = password[:len(password)-1]
+			}
+		default:
+			password = append(password, key[0])
+		}
+	}
+}
+
+// lookUpTokenSource returns the TokenSource for OAuth2 authentication, or nil if no
+// token has been configured. The token is taken from --token, --token-file, obtained
+// from the token endpoint set via --token-url or taken from BUNEARY_TOKEN.
+//
+// The client secret for the token endpoint is taken from --client-secret or the
+// BUNEARY_CLIENT_SECRET environment variable.
+func lookUpTokenSource(options *globalOptions) (buneary.TokenSource, error) {
+	switch {
+	case options.token != "":
+		return buneary.StaticToken(options.token), nil
+	case options.tokenFile != "":
+		// Fail early instead of on the first request.
+		source := tokenFile(options.tokenFile)
+		if _, err := source.Token(options.ctx); err != nil {
+			return nil, err
+		}
+		return source, nil
+	case options.tokenURL != "":
+		secret := options.clientSecret
+		if secret == "" {
+			secret = os.Getenv(envClientSecret)
+		}
+		source := &buneary.ClientCredentials{
+			TokenURL:     options.tokenURL,
+			ClientID:     options.clientID,
+			ClientSecret: secret,
+			Scopes:       options.scopes,
+		}
+		return source, nil
+	}
+
+	if token := os.Getenv(envToken); token != "" {
+		return buneary.StaticToken(token), nil
+	}
+
+	return nil, nil
+}
+
+// tokenFile is a TokenSource reading the token from a file. The file is read for each
+// token, so that tokens rotated by another process, e.g. a Kubernetes service account
+// token, are picked up.
+type tokenFile string
+
+// Token implements buneary.TokenSource.Token.
+func (t tokenFile) Token(_ context.Context) (string, error) {
+	data, err := ioutil.ReadFile(string(t))
+	if err != nil {
+		return "", fmt.Errorf("reading token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// newTLSConfig creates the TLS configuration for AMQP connections from the --cert,
+// --key and --cacert flags, or returns nil if none of them has been set. Without
+// --cacert, the server certificate is verified using the system's CA certificates.
+func newTLSConfig(options *globalOptions) (*tls.Config, error) {
+	if options.cert == "" && options.caCert == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{}
+
+	if options.cert != "" {
+		certificate, err := tls.LoadX509KeyPair(options.cert, options.key)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	if options.caCert != "" {
+		data, err := ioutil.ReadFile(options.caCert)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificates: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no CA certificates found in %s", options.caCert)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/spf13/cobra"
+	"github.com/streadway/amqp"
+)
+
+// restoreBatchSize is the maximum number of messages published at once when restoring
+// a dump file, so that large dump files don't have to be loaded into memory.
+const restoreBatchSize = 1000
+
+// dumpRecord represents a single message in a dump file. Dump files contain one JSON
+// record per line, where the body is encoded as Base64.
+type dumpRecord struct {
+	Exchange   string               `json:"exchange"`
+	RoutingKey string               `json:"routing_key"`
+	Headers    map[string]dumpValue `json:"headers,omitempty"`
+	Properties dumpProperties       `json:"properties"`
+	Body       []byte               `json:"body"`
+}
+
+// newDumpRecord converts the given message into a dumpRecord.
+func newDumpRecord(message buneary.Message) (dumpRecord, error) {
+	record := dumpRecord{
+		Exchange:   message.Target.Name,
+		RoutingKey: message.RoutingKey,
+		Properties: newDumpProperties(message.Properties),
+		Body:       message.Body,
+	}
+
+	if len(message.Headers) > 0 {
+		record.Headers = make(map[string]dumpValue, len(message.Headers))
+
+		for key, value := range message.Headers {
+			dumped, err := newDumpValue(value)
+			if err != nil {
+				return dumpRecord{}, fmt.Errorf("header %s: %w", key, err)
+			}
+			record.Headers[key] = dumped
+		}
+	}
+
+	return record, nil
+}
+
+// message converts the record back into a message. Unless exchange is empty, the
+// message is published to that exchange instead of the original one.
+func (r dumpRecord) message(exchange string) (buneary.Message, error) {
+	if exchange == "" {
+		exchange = r.Exchange
+	}
+
+	message := buneary.Message{
+		Target:     buneary.Exchange{Name: exchange},
+		RoutingKey: r.RoutingKey,
+		Properties: r.Properties.messageProperties(),
+		Body:       r.Body,
+	}
+
+	if len(r.Headers) > 0 {
+		message.Headers = make(amqp.Table, len(r.Headers))
+
+		for key, dumped := range r.Headers {
+			value, err := dumped.headerValue()
+			if err != nil {
+				return buneary.Message{}, fmt.Errorf("header %s: %w", key, err)
+			}
+			message.Headers[key] = value
+		}
+	}
+
+	return message, nil
+}
+
+// dumpValue represents a header value in a dump file. JSON can't distinguish between
+// the various AMQP field types, so the value is stored along with its type, which
+// allows restoring it exactly as it has been read.
+type dumpValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// newDumpValue converts the given header value into a dumpValue. An error is returned
+// if the value isn't of a type supported by AMQP.
+func newDumpValue(value interface{}) (dumpValue, error) {
+	var (
+		typ     string
+		encoded interface{} = value
+	)
+
+	switch v := value.(type) {
+	case nil:
+		return dumpValue{Type: "void"}, nil
+	case bool:
+		typ = "bool"
+	case byte:
+		typ = "byte"
+	case int16:
+		typ = "int16"
+	case int:
+		// Plain integers are sent as 32-bit integers.
+		typ, encoded = "int32", int32(v)
+	case int32:
+		typ = "int32"
+	case int64:
+		typ = "int64"
+	case float32:
+		typ = "float32"
+	case float64:
+		typ = "float64"
+	case amqp.Decimal:
+		typ = "decimal"
+	case string:
+		typ = "string"
+	case []byte:
+		typ = "bytes"
+	case time.Time:
+		typ = "timestamp"
+	case []interface{}:
+		items := make([]dumpValue, len(v))
+		for i, item := range v {
+			dumped, err := newDumpValue(item)
+			if err != nil {
+				return dumpValue{}, err
+			}
+			items[i] = dumped
+		}
+		typ, encoded = "array", items
+	case amqp.Table:
+		fields := make(map[string]dumpValue, len(v))
+		for key, item := range v {
+			dumped, err := newDumpValue(item)
+			if err != nil {
+				return dumpValue{}, err
+			}
+			fields[key] = dumped
+		}
+		typ, encoded = "table", fields
+	default:
+		return dumpValue{}, fmt.Errorf("unsupported header type %T", value)
+	}
+
+	raw, err := json.Marshal(encoded)
+	if err != nil {
+		return dumpValue{}, err
+	}
+
+	return dumpValue{Type: typ, Value: raw}, nil
+}
+
+// headerValue converts the dumped value back into a header value of its original type.
+func (d dumpValue) headerValue() (interface{}, error) {
+	var (
+		value interface{}
+		err   error
+	)
+
+	switch d.Type {
+	case "void":
+		return nil, nil
+	case "bool":
+		value, err = decodeDumpValue(d.Value, new(bool))
+	case "byte":
+		value, err = decodeDumpValue(d.Value, new(byte))
+	case "int16":
+		value, err = decodeDumpValue(d.Value, new(int16))
+	case "int32":
+		value, err = decodeDumpValue(d.Value, new(int32))
+	case "int64":
+		value, err = decodeDumpValue(d.Value, new(int64))
+	case "float32":
+		value, err = decodeDumpValue(d.Value, new(float32))
+	case "float64":
+		value, err = decodeDumpValue(d.Value, new(float64))
+	case "decimal":
+		value, err = decodeDumpValue(d.Value, new(amqp.Decimal))
+	case "string":
+		value, err = decodeDumpValue(d.Value, new(string))
+	case "bytes":
+		value, err = decodeDumpValue(d.Value, new([]byte))
+	case "timestamp":
+		value, err = decodeDumpValue(d.Value, new(time.Time))
+	case "array":
+		var items []dumpValue
+		if err := json.Unmarshal(d.Value, &items); err != nil {
+			return nil, err
+		}
+		array := make([]interface{}, len(items))
+		for i, item := range items {
+			if array[i], err = item.headerValue(); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	case "table":
+		var fields map[string]dumpValue
+		if err := json.Unmarshal(d.Value, &fields); err != nil {
+			return nil, err
+		}
+		table := make(amqp.Table, len(fields))
+		for key, field := range fields {
+			if table[key], err = field.headerValue(); err != nil {
+				return nil, err
+			}
+		}
+		return table, nil
+	default:
+		return nil, fmt.Errorf("unsupported header type %q", d.Type)
+	}
+
+	return value, err
+}
+
+// decodeDumpValue decodes the given JSON value into target, which has to be a pointer,
+// and returns the value target points to.
+func decodeDumpValue(raw json.RawMessage, target interface{}) (interface{}, error) {
+	if err := json.Unmarshal(raw, target); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(target).Elem().Interface(), nil
+}
+
+// dumpProperties represents the properties of a message in a dump file. Its timestamp
+// shadows the one of the embedded properties, so that messages without a timestamp
+// are dumped and restored without one instead of using the zero time.
+type dumpProperties struct {
+	buneary.MessageProperties
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// newDumpProperties converts the given message properties into dumpProperties.
+func newDumpProperties(properties buneary.MessageProperties) dumpProperties {
+	dumped := dumpProperties{MessageProperties: properties}
+
+	if !properties.Timestamp.IsZero() {
+		dumped.Timestamp = &properties.Timestamp
+	}
+
+	return dumped
+}
+
+// messageProperties converts the dumped properties back into message properties.
+// Dump files written by previous versions contain the zero time for messages without
+// a timestamp, which is restored without a timestamp as well.
+func (d dumpProperties) messageProperties() buneary.MessageProperties {
+	properties := d.MessageProperties
+
+	if d.Timestamp != nil {
+		properties.Timestamp = *d.Timestamp
+	}
+
+	return properties
+}
+
+// dumpOptions defines options for dumping messages into a file.
+type dumpOptions struct {
+	*globalOptions
+	file    string
+	requeue bool
+	force   bool
+}
+
+// dumpCommand creates the `buneary dump` command, making sure that exactly two
+// arguments are passed.
+func dumpCommand(options *globalOptions) *cobra.Command {
+	dumpOptions := &dumpOptions{
+		globalOptions: options,
+	}
+
+	dump := &cobra.Command{
+		Use:               "dump <ADDRESS> <QUEUE NAME>",
+		Short:             "Dump all messages in a queue into a file",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses, completeQueueNames),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDump(dumpOptions, args)
+		},
+	}
+
+	dump.Flags().
+		StringVar(&dumpOptions.file, "file", "", "the file to write the messages to, - for stdout")
+	dump.Flags().
+		BoolVar(&dumpOptions.requeue, "requeue", false, "re-queue the messages after dumping them")
+	dump.Flags().
+		BoolVarP(&dumpOptions.force, "force", "f", false, "force running this command without opt-in")
+
+	_ = dump.MarkFlagRequired("file")
+
+	return dump
+}
+
+// runDump dumps all messages in a queue by reading the command line data, setting
+// the configuration and calling the ReadMessages function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// Each message is acknowledged only after it has been written to the file, so no
+// message gets lost if writing the file fails.
+//
+// When dumping to stdout, all prompts are written to stderr instead, so that they
+// don't end up in the dump.
+func runDump(options *dumpOptions, args []string) error {
+	var (
+		address = args[0]
+		queue   = args[1]
+	)
+
+	if options.file == "-" {
+		options.out = os.Stderr
+	}
+
+	// De-queueing the messages is refused in dry-run mode, so fail before asking the
+	// user and truncating the file.
+	if options.dryRun && !options.requeue {
+		return fmt.Errorf("dumping messages: %w", buneary.ErrDryRun)
+	}
+
+	message := "Dumping the messages from the queue will de-queue them." +
+		"To re-queue them, pass the --requeue flag. Do you want to continue?"
+
+	if !options.requeue && !options.force {
+		ok, err := confirm(options.globalOptions, message)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+	}
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	var file io.Writer = os.Stdout
+
+	if options.file != "-" {
+		f, err := os.Create(options.file)
+		if err != nil {
+			return fmt.Errorf("creating dump file: %w", err)
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		file = f
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+
+	count, err := provider.ReadMessages(options.ctx, buneary.Queue{Name: queue}, options.requeue, func(message buneary.Message) error {
+		record, err := newDumpRecord(message)
+		if err != nil {
+			return fmt.Errorf("dumping message: %w", err)
+		}
+
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("writing message: %w", err)
+		}
+
+		// Flush the message before it gets acknowledged.
+		return writer.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	if options.file != "-" {
+		output := fmt.Sprintf("%d messages dumped successfully\n", count)
+		_, _ = options.out.WriteString(output)
+	}
+
+	return nil
+}
+
+// restoreOptions defines options for restoring messages from a file.
+type restoreOptions struct {
+	*globalOptions
+	exchange string
+}
+
+// restoreCommand creates the `buneary restore` command, making sure that exactly two
+// arguments are passed.
+func restoreCommand(options *globalOptions) *cobra.Command {
+	restoreOptions := &restoreOptions{
+		globalOptions: options,
+	}
+
+	restore := &cobra.Command{
+		Use:   "restore <ADDRESS> <FILE>",
+		Short: "Publish all messages from a dump file",
+		Args:  cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return completeAddresses(options, args), cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRestore(restoreOptions, args)
+		},
+	}
+
+	restore.Flags().
+		StringVar(&restoreOptions.exchange, "exchange", "", "publish to this exchange instead of the original one")
+
+	_ = restore.RegisterFlagCompletionFunc("exchange", completeFlag(options, completeExchangeNames))
+
+	return restore
+}
+
+// runRestore restores the messages from a dump file by reading the command line data,
+// setting the configuration and calling the PublishMessages function. In case the
+// password or both the user and password aren't provided, it will go into interactive
+// mode.
+//
+// The messages are published with their original routing keys and properties. Unless
+// the --exchange flag is used, they're sent to their original exchanges.
+func runRestore(options *restoreOptions, args []string) error {
+	var (
+		address = args[0]
+		path    = args[1]
+	)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening dump file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	count, err := restoreMessages(options.ctx, provider, file, options.exchange)
+	if err != nil {
+		return fmt.Errorf("restoring messages after %d restored: %w", count, err)
+	}
+
+	output := fmt.Sprintf("%d messages restored successfully\n", count)
+	printSuccess(options.globalOptions, output)
+
+	return nil
+}
+
+// restoreMessages reads the records from the given dump file and publishes them in
+// batches of at most restoreBatchSize messages, returning the number of restored
+// messages. Unless exchange is empty, all messages are published to that exchange.
+func restoreMessages(ctx context.Context, provider buneary.Provider, r io.Reader, exchange string) (int, error) {
+	var (
+		decoder = json.NewDecoder(r)
+		batch   = make([]buneary.Message, 0, restoreBatchSize)
+		read    int
+		count   int
+	)
+
+	publish := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		published, err := provider.PublishMessages(ctx, batch)
+		count += published
+		batch = batch[:0]
+
+		return err
+	}
+
+	for {
+		var record dumpRecord
+
+		if err := decoder.Decode(&record); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return count, fmt.Errorf("reading message %d: %w", read+1, err)
+		}
+
+		read++
+
+		message, err := record.message(exchange)
+		if err != nil {
+			return count, fmt.Errorf("reading message %d: %w", read, err)
+		}
+
+		batch = append(batch, message)
+
+		if len(batch) == restoreBatchSize {
+			if err := publish(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if err := publish(); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/streadway/amqp"
+)
+
+// dumpMessages writes the given messages into a dump file like `buneary dump` does.
+func dumpMessages(t *testing.T, messages []buneary.Message) *bytes.Buffer {
+	t.Helper()
+
+	var file bytes.Buffer
+	encoder := json.NewEncoder(&file)
+
+	for _, message := range messages {
+		record, err := newDumpRecord(message)
+		if err != nil {
+			t.Fatalf("dumping message: %v", err)
+		}
+		if err := encoder.Encode(record); err != nil {
+			t.Fatalf("writing message: %v", err)
+		}
+	}
+
+	return &file
+}
+
+// publishRecorder is a Provider recording the messages passed to PublishMessages. It
+// fails with err once it has been called failAfter times, unless failAfter is 0.
+type publishRecorder struct {
+	buneary.Provider
+	batches   [][]buneary.Message
+	failAfter int
+	err       error
+}
+
+// PublishMessages implements buneary.Provider.PublishMessages.
+func (p *publishRecorder) PublishMessages(_ context.Context, messages []buneary.Message) (int, error) {
+	if p.failAfter > 0 && len(p.batches) == p.failAfter {
+		return 0, p.err
+	}
+
+	// The caller may re-use the slice for the next batch.
+	p.batches = append(p.batches, append([]buneary.Message(nil), messages...))
+
+	return len(messages), nil
+}
+
+func TestDumpRecord_RoundTrip(t *testing.T) {
+	timestamp := time.Unix(1617187200, 0).UTC()
+
+	message := buneary.Message{
+		Target:     buneary.Exchange{Name: "orders"},
+		RoutingKey: "orders.eu",
+		Headers: amqp.Table{
+			"bool":      true,
+			"byte":      byte(7),
+			"int16":     int16(-16),
+			"int32":     int32(32),
+			"int64":     int64(1) << 60,
+			"float32":   float32(1.5),
+			"float64":   2.25,
+			"decimal":   amqp.Decimal{Scale: 2, Value: 1234},
+			"string":    "acme",
+			"bytes":     []byte{0, 1, 0xfe, 0xff},
+			"timestamp": timestamp,
+			"void":      nil,
+			"array":     []interface{}{int32(1), "two", []byte("three")},
+			"table": amqp.Table{
+				"count":    int64(4),
+				"received": timestamp,
+				"nested":   amqp.Table{"flag": false},
+			},
+		},
+		Properties: buneary.MessageProperties{
+			ContentType:   "application/json",
+			DeliveryMode:  2,
+			Priority:      5,
+			CorrelationID: "abc",
+			Timestamp:     timestamp,
+		},
+		Body: []byte(`{"amount": 100}`),
+	}
+
+	file := dumpMessages(t, []buneary.Message{message})
+
+	var record dumpRecord
+
+	if err := json.NewDecoder(file).Decode(&record); err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+
+	restored, err := record.message("")
+	if err != nil {
+		t.Fatalf("restoring message: %v", err)
+	}
+
+	if !reflect.DeepEqual(restored, message) {
+		t.Errorf("expected restored message\n%#v\ngot\n%#v", message, restored)
+	}
+
+	// Each header has to keep its type, not only its value.
+	for key, value := range message.Headers {
+		if want, got := reflect.TypeOf(value), reflect.TypeOf(restored.Headers[key]); want != got {
+			t.Errorf("expected header %s to be restored as %v, got %v", key, want, got)
+		}
+	}
+}
+
+func TestDumpRecord_Message(t *testing.T) {
+	record := dumpRecord{Exchange: "orders", RoutingKey: "key"}
+
+	tests := []struct {
+		exchange string
+		want     string
+	}{
+		{exchange: "", want: "orders"},
+		{exchange: "orders-restored", want: "orders-restored"},
+	}
+
+	for _, test := range tests {
+		message, err := record.message(test.exchange)
+		if err != nil {
+			t.Fatalf("restoring message: %v", err)
+		}
+
+		if message.Target.Name != test.want {
+			t.Errorf("expected exchange %s, got %s", test.want, message.Target.Name)
+		}
+		if message.Headers != nil || !message.Properties.Timestamp.IsZero() {
+			t.Errorf("expected no headers and no timestamp, got %v and %v", message.Headers,
+				message.Properties.Timestamp)
+		}
+	}
+}
+
+func TestDumpValue_Errors(t *testing.T) {
+	if _, err := newDumpValue(uint32(1)); err == nil {
+		t.Errorf("expected an error for an unsupported header type")
+	}
+
+	tests := []dumpValue{
+		{Type: "uint32", Value: json.RawMessage(`1`)},
+		{Type: "int16", Value: json.RawMessage(`100000`)},
+		{Type: "timestamp", Value: json.RawMessage(`"yesterday"`)},
+		{Type: "array", Value: json.RawMessage(`[{"type":"int8","value":1}]`)},
+	}
+
+	for _, test := range tests {
+		if _, err := test.headerValue(); err == nil {
+			t.Errorf("expected an error for %s %s", test.Type, test.Value)
+		}
+	}
+}
+
+func TestRestoreMessages(t *testing.T) {
+	messages := make([]buneary.Message, 2*restoreBatchSize+restoreBatchSize/2)
+
+	for i := range messages {
+		messages[i] = buneary.Message{
+			Target:     buneary.Exchange{Name: "orders"},
+			RoutingKey: "orders.eu",
+			Headers:    amqp.Table{"sequence": int64(i)},
+			Body:       []byte("hello"),
+		}
+	}
+
+	recorder := &publishRecorder{}
+
+	count, err := restoreMessages(context.Background(), recorder, dumpMessages(t, messages), "")
+	if err != nil {
+		t.Fatalf("restoring messages: %v", err)
+	}
+
+	if count != len(messages) {
+		t.Errorf("expected %d restored messages, got %d", len(messages), count)
+	}
+
+	var restored []buneary.Message
+
+	for i, batch := range recorder.batches {
+		if len(batch) > restoreBatchSize {
+			t.Errorf("expected at most %d messages in batch %d, got %d", restoreBatchSize, i+1, len(batch))
+		}
+		restored = append(restored, batch...)
+	}
+
+	if len(recorder.batches) != 3 {
+		t.Errorf("expected 3 batches, got %d", len(recorder.batches))
+	}
+	if !reflect.DeepEqual(restored, messages) {
+		t.Errorf("expected the messages to be restored in their original order")
+	}
+}
+
+func TestRestoreMessages_Errors(t *testing.T) {
+	publishErr := errors.New("connection closed")
+
+	messages := make([]buneary.Message, restoreBatchSize+1)
+
+	recorder := &publishRecorder{failAfter: 1, err: publishErr}
+
+	count, err := restoreMessages(context.Background(), recorder, dumpMessages(t, messages), "")
+	if !errors.Is(err, publishErr) {
+		t.Errorf("expected error %v, got %v", publishErr, err)
+	}
+	if count != restoreBatchSize {
+		t.Errorf("expected %d restored messages, got %d", restoreBatchSize, count)
+	}
+
+	file := `{"exchange":"orders","routing_key":"key","properties":{},"body":""}` + "\n" + `{"exchange":`
+
+	count, err = restoreMessages(context.Background(), &publishRecorder{}, strings.NewReader(file), "")
+	if err == nil || !strings.Contains(err.Error(), "reading message 2") {
+		t.Errorf("expected an error reading message 2, got %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected no messages to be restored before the error, got %d", count)
+	}
+}
@@ -0,0 +1,573 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+)
+
+// expression is a compiled boolean expression that can be evaluated against messages,
+// e.g. `headers.tenant == "acme" && body.amount > 100`.
+//
+// The following values are available in expressions:
+//
+//	exchange       the exchange the message has been published to
+//	routing_key    the routing key of the message
+//	headers.<key>  a message header
+//	properties.<p> a message property, e.g. properties.content_type
+//	body           the message body as string
+//	body.<field>   a field of a JSON body, e.g. body.customer.id or body.items[0]
+//
+// Keys consisting of other characters than letters, digits and underscores have to
+// be accessed using brackets, e.g. headers["x-tenant"].
+//
+// Values can be compared using ==, !=, <, <=, > and >=, and strings can be matched
+// against regular expressions using =~. Comparisons can be combined using &&, || and
+// !, and grouped using parentheses. Literals are strings in double quotes, numbers,
+// true, false and null. Non-existent values evaluate to null.
+type expression struct {
+	root exprNode
+}
+
+// exprNode is a node of the syntax tree of an expression.
+type exprNode interface {
+	eval(values map[string]interface{}) interface{}
+}
+
+// compileExpression parses the given source into an expression. An error is returned
+// if the source is not a valid expression.
+func compileExpression(source string) (*expression, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].pos)
+	}
+
+	return &expression{root: root}, nil
+}
+
+// matches evaluates the expression against the given message and reports whether
+// the result is truthy.
+func (e *expression) matches(message buneary.Message) bool {
+	return isTruthy(e.root.eval(messageValues(message)))
+}
+
+// messageValues returns the values available in expressions for the given message.
+// The body is parsed as JSON if possible. Otherwise, it is available as string.
+func messageValues(message buneary.Message) map[string]interface{} {
+	// Header tables and properties are converted into plain maps by encoding them
+	// as JSON, which allows accessing nested values just like in the JSON body.
+	var headers, properties map[string]interface{}
+
+	if encoded, err := json.Marshal(message.Headers); err == nil {
+		_ = json.Unmarshal(encoded, &headers)
+	}
+
+	if encoded, err := json.Marshal(message.Properties); err == nil {
+		_ = json.Unmarshal(encoded, &properties)
+	}
+
+	var body interface{}
+
+	if err := json.Unmarshal(message.Body, &body); err != nil {
+		body = string(message.Body)
+	}
+
+	return map[string]interface{}{
+		"exchange":    message.Target.Name,
+		"routing_key": message.RoutingKey,
+		"headers":     headers,
+		"properties":  properties,
+		"body":        body,
+	}
+}
+
+// exprTokenKind represents the kind of a token.
+type exprTokenKind int
+
+const (
+	exprOperator exprTokenKind = iota
+	exprString
+	exprNumber
+	exprIdent
+)
+
+// exprToken is a single token of an expression, e.g. an operator or a literal.
+type exprToken struct {
+	kind exprTokenKind
+	text string
+	pos  int
+}
+
+// exprOperators lists all operators, two-character operators first.
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "<", ">", "!", "(", ")", ".", "[", "]"}
+
+// tokenizeExpression splits the given source into tokens.
+func tokenizeExpression(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '"':
+			var b strings.Builder
+			start := i
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, exprToken{kind: exprString, text: b.String(), pos: start})
+
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: exprNumber, text: string(runes[start:i]), pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: exprIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, exprToken{kind: exprOperator, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// exprParser is a recursive descent parser for expressions.
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+// peek returns the current token without consuming it, or nil at the end.
+func (p *exprParser) peek() *exprToken {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+// accept consumes the current token if it is the given operator.
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t != nil && t.kind == exprOperator && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// parseOr parses a disjunction: and ("||" and)*
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseAnd parses a conjunction: not ("&&" not)*
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseNot parses a negation: "!" not | comparison
+func (p *exprParser) parseNot() (exprNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+// parseComparison parses a comparison: primary (operator primary)?
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "=~"} {
+		if !p.accept(op) {
+			continue
+		}
+
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+
+		c := &comparisonNode{op: op, left: left, right: right}
+
+		// Regular expressions given as literals are compiled only once.
+		if l, ok := right.(*literalNode); ok && op == "=~" {
+			pattern, _ := l.value.(string)
+			if c.pattern, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid regular expression: %w", err)
+			}
+		}
+
+		return c, nil
+	}
+
+	return left, nil
+}
+
+// parsePrimary parses a literal, a path or a parenthesized expression.
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	p.pos++
+
+	switch {
+	case t.kind == exprOperator && t.text == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing closing parenthesis for position %d", t.pos)
+		}
+		return inner, nil
+
+	case t.kind == exprString:
+		return &literalNode{value: t.text}, nil
+
+	case t.kind == exprNumber:
+		number, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+		return &literalNode{value: number}, nil
+
+	case t.kind == exprIdent && t.text == "true":
+		return &literalNode{value: true}, nil
+
+	case t.kind == exprIdent && t.text == "false":
+		return &literalNode{value: false}, nil
+
+	case t.kind == exprIdent && t.text == "null":
+		return &literalNode{value: nil}, nil
+
+	case t.kind == exprIdent:
+		path := &pathNode{segments: []interface{}{t.text}}
+
+		for {
+			switch {
+			case p.accept("."):
+				next := p.peek()
+				if next == nil || next.kind != exprIdent {
+					return nil, fmt.Errorf("expected field name after position %d", t.pos)
+				}
+				p.pos++
+				path.segments = append(path.segments, next.text)
+
+			case p.accept("["):
+				next := p.peek()
+				if next == nil || (next.kind != exprString && next.kind != exprNumber) {
+					return nil, fmt.Errorf("expected key or index after position %d", t.pos)
+				}
+				p.pos++
+				if next.kind == exprNumber {
+					index, err := strconv.Atoi(next.text)
+					if err != nil {
+						return nil, fmt.Errorf("invalid index %q at position %d", next.text, next.pos)
+					}
+					path.segments = append(path.segments, index)
+				} else {
+					path.segments = append(path.segments, next.text)
+				}
+				if !p.accept("]") {
+					return nil, fmt.Errorf("missing closing bracket after position %d", next.pos)
+				}
+
+			default:
+				return path, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+// literalNode is a string, number, boolean or null literal.
+type literalNode struct {
+	value interface{}
+}
+
+func (l *literalNode) eval(_ map[string]interface{}) interface{} {
+	return l.value
+}
+
+// pathNode is a path to a value like headers.tenant or body.items[0]. Each segment
+// is either a string key or an integer index.
+type pathNode struct {
+	segments []interface{}
+}
+
+func (n *pathNode) eval(values map[string]interface{}) interface{} {
+	var current interface{} = values
+
+	for _, segment := range n.segments {
+		switch s := segment.(type) {
+		case string:
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			current = m[s]
+		case int:
+			a, ok := current.([]interface{})
+			if !ok || s < 0 || s >= len(a) {
+				return nil
+			}
+			current = a[s]
+		}
+	}
+
+	return current
+}
+
+// logicalNode is a conjunction or - if or is true - a disjunction.
+type logicalNode struct {
+	or          bool
+	left, right exprNode
+}
+
+func (n *logicalNode) eval(values map[string]interface{}) interface{} {
+	left := isTruthy(n.left.eval(values))
+
+	if n.or {
+		return left || isTruthy(n.right.eval(values))
+	}
+
+	return left && isTruthy(n.right.eval(values))
+}
+
+// notNode is a negation.
+type notNode struct {
+	operand exprNode
+}
+
+func (n *notNode) eval(values map[string]interface{}) interface{} {
+	return !isTruthy(n.operand.eval(values))
+}
+
+// comparisonNode is a comparison of two values. Numbers are compared numerically and
+// strings lexicographically. Values of different types are never equal.
+type comparisonNode struct {
+	op          string
+	left, right exprNode
+	pattern     *regexp.Regexp
+}
+
+func (n *comparisonNode) eval(values map[string]interface{}) interface{} {
+	left := normalizeExprValue(n.left.eval(values))
+	right := normalizeExprValue(n.right.eval(values))
+
+	switch n.op {
+	case "==":
+		return valuesEqual(left, right)
+	case "!=":
+		return !valuesEqual(left, right)
+	case "=~":
+		s, ok := left.(string)
+		if !ok {
+			return false
+		}
+		pattern := n.pattern
+		if pattern == nil {
+			source, ok := right.(string)
+			if !ok {
+				return false
+			}
+			var err error
+			if pattern, err = regexp.Compile(source); err != nil {
+				return false
+			}
+		}
+		return pattern.MatchString(s)
+	}
+
+	cmp, ok := compareValues(left, right)
+	if !ok {
+		return false
+	}
+
+	switch n.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// normalizeExprValue converts all numeric types to float64 and byte slices to strings,
+// so that all values can be compared to literals.
+func normalizeExprValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return string(v)
+	default:
+		return v
+	}
+}
+
+// valuesEqual reports whether two normalized values are equal.
+func valuesEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case nil:
+		return b == nil
+	case float64:
+		bv, ok := b.(float64)
+		return ok && av == bv
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	}
+	return false
+}
+
+// compareValues compares two normalized numbers or strings. It returns false if the
+// values can't be compared.
+func compareValues(a, b interface{}) (int, bool) {
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av < bv:
+			return -1, true
+		case av > bv:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(av, bv), true
+	}
+	return 0, false
+}
+
+// isTruthy reports whether a value is considered true. null, false, empty strings and
+// zero are considered false, all other values true.
+func isTruthy(value interface{}) bool {
+	switch v := normalizeExprValue(value).(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/streadway/amqp"
+)
+
+// testMessage is the message the expressions in the tests are evaluated against.
+var testMessage = buneary.Message{
+	Target:     buneary.Exchange{Name: "orders"},
+	RoutingKey: "orders.eu.created",
+	Headers: amqp.Table{
+		"tenant":   "acme",
+		"x-tenant": "acme-eu",
+		"retries":  int32(3),
+	},
+	Properties: buneary.MessageProperties{ContentType: "application/json"},
+	Body:       []byte(`{"amount": 150, "paid": true, "customer": {"id": "c-1"}, "items": [{"sku": "a"}, {"sku": "b"}], "note": null}`),
+}
+
+func TestCompileExpression_Matches(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       bool
+	}{
+		// Precedence and grouping.
+		{name: "and before or", expression: `false && false || true`, want: true},
+		{name: "and before or on the right", expression: `true || false && false`, want: true},
+		{name: "parentheses", expression: `(true || false) && false`, want: false},
+		{name: "not before and", expression: `!false && true`, want: true},
+		{name: "not with parentheses", expression: `!(true && false)`, want: true},
+		{name: "double negation", expression: `!!true`, want: true},
+		{name: "comparison before and", expression: `body.amount > 100 && headers.tenant == "acme"`, want: true},
+
+		// Paths and indices.
+		{name: "exchange", expression: `exchange == "orders"`, want: true},
+		{name: "routing key", expression: `routing_key == "orders.eu.created"`, want: true},
+		{name: "header", expression: `headers.tenant == "acme"`, want: true},
+		{name: "header in brackets", expression: `headers["x-tenant"] == "acme-eu"`, want: true},
+		{name: "integer header", expression: `headers.retries == 3`, want: true},
+		{name: "property", expression: `properties.content_type == "application/json"`, want: true},
+		{name: "nested field", expression: `body.customer.id == "c-1"`, want: true},
+		{name: "index", expression: `body.items[1].sku == "b"`, want: true},
+		{name: "key in brackets", expression: `body["customer"]["id"] == "c-1"`, want: true},
+		{name: "index out of range", expression: `body.items[2] == null`, want: true},
+		{name: "index on object", expression: `body.customer[0] == null`, want: true},
+		{name: "missing field", expression: `body.missing == null`, want: true},
+		{name: "null field", expression: `body.note == null`, want: true},
+		{name: "missing field is falsy", expression: `body.missing`, want: false},
+		{name: "boolean field", expression: `body.paid`, want: true},
+
+		// Comparisons.
+		{name: "number less than", expression: `body.amount < 200`, want: true},
+		{name: "number less or equal", expression: `body.amount <= 150`, want: true},
+		{name: "number greater or equal", expression: `body.amount >= 151`, want: false},
+		{name: "negative number", expression: `body.amount > -1`, want: true},
+		{name: "string order", expression: `headers.tenant < "b"`, want: true},
+		{name: "not equal", expression: `headers.tenant != "other"`, want: true},
+
+		// Regular expressions.
+		{name: "regex match", expression: `routing_key =~ "^orders\\.eu\\."`, want: true},
+		{name: "regex mismatch", expression: `routing_key =~ "^orders\\.us\\."`, want: false},
+		{name: "regex from value", expression: `headers.tenant =~ headers.tenant`, want: true},
+		{name: "regex on number", expression: `body.amount =~ "150"`, want: false},
+
+		// Type mismatches.
+		{name: "number equals string", expression: `body.amount == "150"`, want: false},
+		{name: "number not equals string", expression: `body.amount != "150"`, want: true},
+		{name: "string less than number", expression: `headers.tenant < 1`, want: false},
+		{name: "string greater or equal number", expression: `headers.tenant >= 1`, want: false},
+		{name: "boolean equals number", expression: `body.paid == 1`, want: false},
+		{name: "null less than number", expression: `body.missing < 1`, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expression, err := compileExpression(test.expression)
+			if err != nil {
+				t.Fatalf("compiling %s: %v", test.expression, err)
+			}
+
+			if got := expression.matches(testMessage); got != test.want {
+				t.Errorf("expected %s to be %v, got %v", test.expression, test.want, got)
+			}
+		})
+	}
+}
+
+func TestCompileExpression_PlainBody(t *testing.T) {
+	message := buneary.Message{Body: []byte("not JSON")}
+
+	expression, err := compileExpression(`body =~ "^not" && body.field == null`)
+	if err != nil {
+		t.Fatalf("compiling expression: %v", err)
+	}
+
+	if !expression.matches(message) {
+		t.Errorf("expected a body that isn't JSON to be matched as string")
+	}
+}
+
+func TestCompileExpression_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{name: "empty", expression: ``, wantErr: "unexpected end of expression"},
+		{name: "unterminated string", expression: `headers.tenant == "acme`, wantErr: "unterminated string at position 18"},
+		{name: "unexpected character", expression: `headers.tenant == 'acme'`, wantErr: "unexpected character '\\'' at position 18"},
+		{name: "missing operand", expression: `body.amount >`, wantErr: "unexpected end of expression"},
+		{name: "missing closing parenthesis", expression: `(true || false`, wantErr: "missing closing parenthesis for position 0"},
+		{name: "trailing token", expression: `true false`, wantErr: `unexpected "false" at position 5`},
+		{name: "missing field name", expression: `body.`, wantErr: "expected field name after position 0"},
+		{name: "missing closing bracket", expression: `body.items[0`, wantErr: "missing closing bracket after position 11"},
+		{name: "invalid index", expression: `body.items[1.5]`, wantErr: `invalid index "1.5" at position 11`},
+		{name: "identifier as index", expression: `body.items[first]`, wantErr: "expected key or index after position 0"},
+		{name: "invalid number", expression: `body.amount > 1.2.3`, wantErr: `invalid number "1.2.3" at position 14`},
+		{name: "invalid regex", expression: `routing_key =~ "("`, wantErr: "invalid regular expression"},
+		{name: "operator as operand", expression: `== 1`, wantErr: `unexpected "==" at position 0`},
+		// Dashes aren't part of identifiers, so the key has to be put in brackets.
+		{name: "dash in field name", expression: `body.amount-1 > 0`, wantErr: `unexpected "-1" at position 11`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := compileExpression(test.expression)
+			if err == nil {
+				t.Fatalf("expected an error for %s", test.expression)
+			}
+
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("expected error containing %q, got %q", test.wantErr, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// createFederationUpstreamOptions defines options for creating a new federation
+// upstream.
+type createFederationUpstreamOptions struct {
+	*globalOptions
+	expires     int
+	messageTTL  int
+	maxHops     int
+	prefetch    int
+	ackMode     string
+	trustUserID bool
+	exchange    string
+	queue       string
+}
+
+// createFederationUpstreamCommand creates the `buneary create federation-upstream`
+// command, making sure that exactly three arguments are passed.
+func createFederationUpstreamCommand(options *globalOptions) *cobra.Command {
+	createUpstreamOptions := &createFederationUpstreamOptions{
+		globalOptions: options,
+	}
+
+	createUpstream := &cobra.Command{
+		Use:               "federation-upstream <ADDRESS> <NAME> <URI>",
+		Short:             "Create a new federation upstream",
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCreateFederationUpstream(createUpstreamOptions, args)
+		},
+	}
+
+	createUpstream.Flags().
+		IntVar(&createUpstreamOptions.expires, "expires", 0, "expire unused upstream queues after this many milliseconds")
+	createUpstream.Flags().
+		IntVar(&createUpstreamOptions.messageTTL, "message-ttl", 0, "expire messages in upstream queues after this many milliseconds")
+	createUpstream.Flags().
+		IntVar(&createUpstreamOptions.maxHops, "max-hops", 1, "the maximum number of federation links a message may traverse")
+	createUpstream.Flags().
+		IntVar(&createUpstreamOptions.prefetch, "prefetch", 1000, "the maximum number of unacknowledged messages per link")
+	createUpstream.Flags().
+		StringVar(&createUpstreamOptions.ackMode, "ack-mode", string(buneary.OnConfirm), "the acknowledgement mode: on-confirm, on-publish or no-ack")
+	createUpstream.Flags().
+		BoolVar(&createUpstreamOptions.trustUserID, "trust-user-id", false, "pass on the user-id property of messages unchanged")
+	createUpstream.Flags().
+		StringVar(&createUpstreamOptions.exchange, "exchange", "", "the upstream exchange, defaults to the name of the federated exchange")
+	createUpstream.Flags().
+		StringVar(&createUpstreamOptions.queue, "queue", "", "the upstream queue, defaults to the name of the federated queue")
+
+	return createUpstream
+}
+
+// runCreateFederationUpstream creates a new federation upstream by reading the command
+// line data, setting the configuration and calling the CreateFederationUpstream
+// function. In case the password or both the user and password aren't provided, it
+// will go into interactive mode.
+func runCreateFederationUpstream(options *createFederationUpstreamOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+		uri     = args[2]
+	)
+
+	ackMode := buneary.AckMode(options.ackMode)
+
+	if ackMode != buneary.OnConfirm && ackMode != buneary.OnPublish && ackMode != buneary.NoAck {
+		return fmt.Errorf("unsupported ack mode: %s", options.ackMode)
+	}
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	upstream := buneary.FederationUpstream{
+		Name:          name,
+		URI:           uri,
+		Expires:       options.expires,
+		MessageTTL:    options.messageTTL,
+		MaxHops:       options.maxHops,
+		PrefetchCount: options.prefetch,
+		AckMode:       ackMode,
+		TrustUserID:   options.trustUserID,
+		Exchange:      options.exchange,
+		Queue:         options.queue,
+	}
+
+	if err := provider.CreateFederationUpstream(options.ctx, upstream); err != nil {
+		return err
+	}
+
+	_, _ = options.out.WriteString("federation upstream created successfully\n")
+
+	return nil
+}
+
+// getFederationUpstreamsCommand creates the `buneary get federation-upstreams`
+// command, making sure that exactly one argument is passed.
+func getFederationUpstreamsCommand(options *globalOptions) *cobra.Command {
+	getUpstreams := &cobra.Command{
+		Use:               "federation-upstreams <ADDRESS>",
+		Short:             "Get all federation upstreams",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetFederationUpstreams(options, args)
+		},
+	}
+
+	return getUpstreams
+}
+
+// getFederationUpstreamCommand creates the `buneary get federation-upstream` command,
+// making sure that exactly two arguments are passed.
+func getFederationUpstreamCommand(options *globalOptions) *cobra.Command {
+	getUpstream := &cobra.Command{
+		Use:               "federation-upstream <ADDRESS> <NAME>",
+		Short:             "Get a single federation upstream",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetFederationUpstreams(options, args)
+		},
+	}
+
+	return getUpstream
+}
+
+// runGetFederationUpstreams either returns all upstreams or - if an upstream name has
+// been specified as second argument - a single upstream. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// The upstream URIs are omitted from the table since they may contain credentials.
+// They're still included in the JSON output.
+func runGetFederationUpstreams(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	// The default filter will let pass all upstreams regardless of their names.
+	filter := func(_ buneary.FederationUpstream) bool {
+		return true
+	}
+
+	// However, if an upstream name has been specified as second argument, only that
+	// particular upstream should be returned.
+	if len(args) > 1 {
+		filter = func(upstream buneary.FederationUpstream) bool {
+			return upstream.Name == args[1]
+		}
+	}
+
+	upstreams, err := provider.GetFederationUpstreams(options.ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options, upstreams)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Expires", "Message TTL", "Max Hops", "Prefetch", "Ack Mode", "Trust User ID"})
+
+	for _, upstream := range upstreams {
+		row := make([]string, 7)
+		row[0] = upstream.Name
+		row[1] = millisecondsToString(upstream.Expires)
+		row[2] = millisecondsToString(upstream.MessageTTL)
+		row[3] = strconv.Itoa(upstream.MaxHops)
+		row[4] = strconv.Itoa(upstream.PrefetchCount)
+		row[5] = string(upstream.AckMode)
+		row[6] = boolToString(upstream.TrustUserID)
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getFederationLinksCommand creates the `buneary get federation-links` command,
+// making sure that exactly one argument is passed.
+func getFederationLinksCommand(options *globalOptions) *cobra.Command {
+	getLinks := &cobra.Command{
+		Use:               "federation-links <ADDRESS>",
+		Short:             "Get the status of all federation links",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetFederationLinks(options, args)
+		},
+	}
+
+	return getLinks
+}
+
+// runGetFederationLinks returns the status of all federation links. In case the
+// password or both the user and password aren't provided, it will go into
+// interactive mode.
+func runGetFederationLinks(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	links, err := provider.GetFederationLinks(options.ctx, func(_ buneary.FederationLink) bool {
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options, links)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Upstream", "Type", "Name", "Upstream Name", "Status", "Error"})
+
+	for _, link := range links {
+		row := make([]string, 6)
+		row[0] = link.Upstream
+		row[1] = link.Type
+		row[2] = link.Name
+		row[3] = link.UpstreamName
+		row[4] = link.Status
+		row[5] = link.Error
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// deleteFederationUpstreamCommand creates the `buneary delete federation-upstream`
+// command, making sure that exactly two arguments are passed.
+func deleteFederationUpstreamCommand(options *globalOptions) *cobra.Command {
+	deleteUpstream := &cobra.Command{
+		Use:               "federation-upstream <ADDRESS> <NAME>",
+		Short:             "Delete a federation upstream",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteFederationUpstream(options, args)
+		},
+	}
+
+	return deleteUpstream
+}
+
+// runDeleteFederationUpstream deletes a federation upstream by reading the command
+// line data, setting the configuration and calling the DeleteFederationUpstream
+// function. In case the password or both the user and password aren't provided, it
+// will go into interactive mode.
+func runDeleteFederationUpstream(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	upstream := buneary.FederationUpstream{
+		Name: name,
+	}
+
+	if err := provider.DeleteFederationUpstream(options.ctx, upstream); err != nil {
+		return err
+	}
+
+	_, _ = options.out.WriteString("federation upstream deleted successfully\n")
+
+	return nil
+}
+
+// millisecondsToString formats a number of milliseconds as a duration, where zero
+// means that there is no limit.
+func millisecondsToString(milliseconds int) string {
+	if milliseconds == 0 {
+		return "-"
+	}
+	return (time.Duration(milliseconds) * time.Millisecond).String()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+)
+
+// exitCodes maps the errors reported by the server to distinct exit codes, so that
+// scripts can react to them. All other errors result in exit code 1.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{err: buneary.ErrNotFound, code: 4},
+	{err: buneary.ErrAlreadyExistsWithDifferentProperties, code: 5},
+	{err: buneary.ErrAccessRefused, code: 6},
+	{err: buneary.ErrUnauthorized, code: 7},
+	{err: buneary.ErrResourceLocked, code: 8},
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The first Ctrl-C cancels the context, which aborts all pending operations and
+	// lets the command clean up. A second Ctrl-C terminates buneary immediately.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		cancel()
+		<-signals
+		os.Exit(130)
+	}()
+
+	err := rootCommand(nil).ExecuteContext(ctx)
+	cancel()
+
+	if err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Println("interrupted")
+			os.Exit(130)
+		}
+		for _, e := range exitCodes {
+			if errors.Is(err, e.err) {
+				log.Println(err)
+				os.Exit(e.code)
+			}
+		}
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The content types set for messages encoded by a payloadCodec.
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeAvro     = "avro/binary"
+)
+
+// payloadCodec converts message bodies between JSON and a binary encoding. This
+// allows publishing and reading binary messages in a human-readable form.
+type payloadCodec interface {
+
+	// encode converts a JSON document into the binary encoding.
+	encode(body []byte) ([]byte, error)
+
+	// decode converts a binary message body into a JSON document.
+	decode(body []byte) ([]byte, error)
+
+	// contentType returns the content type of the binary encoding.
+	contentType() string
+}
+
+// newPayloadCodec creates a Protobuf codec if a message type has been given, or an
+// Avro codec if an Avro schema file has been given. If neither of them has been
+// given, it returns nil.
+//
+// The Protobuf message type is resolved from a .proto file or from a compiled
+// descriptor set as produced by protoc --descriptor_set_out.
+func newPayloadCodec(protoFile, protoType, avroSchema string) (payloadCodec, error) {
+	switch {
+	case (protoFile != "" || protoType != "") && avroSchema != "":
+		return nil, errors.New("--proto-file and --avro-schema can't be used together")
+	case protoFile != "" && protoType == "":
+		return nil, errors.New("--proto-type is required when using --proto-file")
+	case protoType != "" && protoFile == "":
+		return nil, errors.New("--proto-file is required when using --proto-type")
+	case protoFile != "":
+		return newProtoCodec(protoFile, protoType)
+	case avroSchema != "":
+		return newAvroCodec(avroSchema)
+	}
+
+	return nil, nil
+}
+
+// protoCodec converts message bodies between Protobuf and its canonical JSON
+// mapping, using a dynamic message built from a message descriptor.
+type protoCodec struct {
+	message protoreflect.MessageDescriptor
+}
+
+// newProtoCodec reads the given .proto file or descriptor set and looks up the
+// message type by its fully-qualified name, e.g. shop.v1.Order.
+func newProtoCodec(file, messageType string) (*protoCodec, error) {
+	var (
+		set *descriptorpb.FileDescriptorSet
+		err error
+	)
+
+	if strings.EqualFold(filepath.Ext(file), ".proto") {
+		set, err = parseProtoFile(file)
+	} else {
+		set, err = readDescriptorSet(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("resolving descriptors: %w", err)
+	}
+
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return nil, fmt.Errorf("finding message type %s: %w", messageType, err)
+	}
+
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message type", messageType)
+	}
+
+	return &protoCodec{message: message}, nil
+}
+
+// parseProtoFile parses a .proto file and returns a descriptor set containing the
+// file itself and all of its imports. Imports are resolved relative to the file's
+// directory.
+func parseProtoFile(file string) (*descriptorpb.FileDescriptorSet, error) {
+	parser := protoparse.Parser{
+		ImportPaths: []string{filepath.Dir(file)},
+	}
+
+	fds, err := parser.ParseFiles(filepath.Base(file))
+	if err != nil {
+		return nil, fmt.Errorf("parsing proto file: %w", err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+
+	// Dependencies have to be added before the files depending on them.
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if seen[fd.GetName()] {
+			return
+		}
+		seen[fd.GetName()] = true
+		for _, dependency := range fd.GetDependencies() {
+			add(dependency)
+		}
+		set.File = append(set.File, fd.AsFileDescriptorProto())
+	}
+
+	for _, fd := range fds {
+		add(fd)
+	}
+
+	return set, nil
+}
+
+// readDescriptorSet reads a binary FileDescriptorSet. To include all imports, it
+// should be compiled using protoc --include_imports.
+func readDescriptorSet(file string) (*descriptorpb.FileDescriptorSet, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading descriptor set: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parsing descriptor set: %w", err)
+	}
+
+	return &set, nil
+}
+
+// encode implements payloadCodec.encode.
+func (p *protoCodec) encode(body []byte) ([]byte, error) {
+	message := dynamicpb.NewMessage(p.message)
+
+	if err := protojson.Unmarshal(body, message); err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", p.message.FullName(), err)
+	}
+
+	return proto.Marshal(message)
+}
+
+// decode implements payloadCodec.decode.
+func (p *protoCodec) decode(body []byte) ([]byte, error) {
+	message := dynamicpb.NewMessage(p.message)
+
+	if err := proto.Unmarshal(body, message); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", p.message.FullName(), err)
+	}
+
+	return protojson.Marshal(message)
+}
+
+// contentType implements payloadCodec.contentType.
+func (p *protoCodec) contentType() string {
+	return contentTypeProtobuf
+}
+
+// avroCodec converts message bodies between the Avro binary encoding and the Avro
+// JSON encoding, where union values are wrapped in an object keyed by their type.
+type avroCodec struct {
+	codec *goavro.Codec
+}
+
+// newAvroCodec reads the given Avro schema file, usually an .avsc file.
+func newAvroCodec(file string) (*avroCodec, error) {
+	schema, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading Avro schema: %w", err)
+	}
+
+	codec, err := goavro.NewCodec(string(schema))
+	if err != nil {
+		return nil, fmt.Errorf("parsing Avro schema: %w", err)
+	}
+
+	return &avroCodec{codec: codec}, nil
+}
+
+// encode implements payloadCodec.encode.
+func (a *avroCodec) encode(body []byte) ([]byte, error) {
+	native, _, err := a.codec.NativeFromTextual(body)
+	if err != nil {
+		return nil, fmt.Errorf("encoding Avro: %w", err)
+	}
+
+	return a.codec.BinaryFromNative(nil, native)
+}
+
+// decode implements payloadCodec.decode.
+func (a *avroCodec) decode(body []byte) ([]byte, error) {
+	native, _, err := a.codec.NativeFromBinary(body)
+	if err != nil {
+		return nil, fmt.Errorf("decoding Avro: %w", err)
+	}
+
+	return a.codec.TextualFromNative(nil, native)
+}
+
+// contentType implements payloadCodec.contentType.
+func (a *avroCodec) contentType() string {
+	return contentTypeAvro
+}
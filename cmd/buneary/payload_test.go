@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// readFixture reads the given file from the testdata directory.
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	return data
+}
+
+// writeDescriptorSet compiles testdata/order.proto into a binary descriptor set like
+// protoc --include_imports --descriptor_set_out does and returns its path.
+func writeDescriptorSet(t *testing.T) string {
+	t.Helper()
+
+	set, err := parseProtoFile(filepath.Join("testdata", "order.proto"))
+	if err != nil {
+		t.Fatalf("parsing proto file: %v", err)
+	}
+
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatalf("encoding descriptor set: %v", err)
+	}
+
+	return writeDataFile(t, "order.desc", string(data))
+}
+
+// assertJSONEqual fails the test if the given JSON documents aren't semantically equal.
+func assertJSONEqual(t *testing.T, want, got []byte) {
+	t.Helper()
+
+	var wantValue, gotValue interface{}
+
+	if err := json.Unmarshal(want, &wantValue); err != nil {
+		t.Fatalf("parsing expected JSON: %v", err)
+	}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("parsing JSON %s: %v", got, err)
+	}
+
+	if !reflect.DeepEqual(wantValue, gotValue) {
+		t.Errorf("expected JSON %s, got %s", want, got)
+	}
+}
+
+func TestProtoCodec_RoundTrip(t *testing.T) {
+	files := map[string]string{
+		"proto file":     filepath.Join("testdata", "order.proto"),
+		"descriptor set": writeDescriptorSet(t),
+	}
+
+	body := readFixture(t, "order.proto.json")
+
+	for name, file := range files {
+		t.Run(name, func(t *testing.T) {
+			codec, err := newPayloadCodec(file, "shop.v1.Order", "")
+			if err != nil {
+				t.Fatalf("creating codec: %v", err)
+			}
+
+			if codec.contentType() != contentTypeProtobuf {
+				t.Errorf("expected content type %s, got %s", contentTypeProtobuf, codec.contentType())
+			}
+
+			encoded, err := codec.encode(body)
+			if err != nil {
+				t.Fatalf("encoding body: %v", err)
+			}
+
+			// The message ID is field 1, encoded as tag 0x0a followed by its length.
+			if !strings.Contains(string(encoded), "\x0a\x0242") {
+				t.Errorf("expected a binary Protobuf message, got %q", encoded)
+			}
+
+			decoded, err := codec.decode(encoded)
+			if err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+
+			assertJSONEqual(t, body, decoded)
+		})
+	}
+}
+
+func TestProtoCodec_Names(t *testing.T) {
+	codec, err := newPayloadCodec(filepath.Join("testdata", "order.proto"), "shop.v1.Order", "")
+	if err != nil {
+		t.Fatalf("creating codec: %v", err)
+	}
+
+	// Field names as declared in the .proto file are accepted as well, but decoding
+	// results in the canonical JSON mapping. Default values are omitted.
+	encoded, err := codec.encode([]byte(`{"id": "7", "created_unix": 5, "status": "STATUS_UNSPECIFIED"}`))
+	if err != nil {
+		t.Fatalf("encoding body: %v", err)
+	}
+
+	decoded, err := codec.decode(encoded)
+	if err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+
+	assertJSONEqual(t, []byte(`{"id": "7", "createdUnix": "5"}`), decoded)
+}
+
+func TestProtoCodec_Errors(t *testing.T) {
+	codec, err := newPayloadCodec(filepath.Join("testdata", "order.proto"), "shop.v1.Order", "")
+	if err != nil {
+		t.Fatalf("creating codec: %v", err)
+	}
+
+	for _, body := range []string{`{"unknown": 1}`, `{"status": "STATUS_SHIPPED"}`, `not JSON`} {
+		if _, err := codec.encode([]byte(body)); err == nil {
+			t.Errorf("expected an error encoding %s", body)
+		}
+	}
+
+	if _, err := codec.decode([]byte{0xff, 0xff}); err == nil {
+		t.Errorf("expected an error decoding an invalid message")
+	}
+}
+
+func TestAvroCodec_RoundTrip(t *testing.T) {
+	codec, err := newPayloadCodec("", "", filepath.Join("testdata", "order.avsc"))
+	if err != nil {
+		t.Fatalf("creating codec: %v", err)
+	}
+
+	if codec.contentType() != contentTypeAvro {
+		t.Errorf("expected content type %s, got %s", contentTypeAvro, codec.contentType())
+	}
+
+	bodies := map[string][]byte{
+		"fixture": readFixture(t, "order.avro.json"),
+		"null union": []byte(`{"id": "43", "status": "PENDING", "items": [], "total": 0, "created": 0,
+			"note": null}`),
+	}
+
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := codec.encode(body)
+			if err != nil {
+				t.Fatalf("encoding body: %v", err)
+			}
+
+			decoded, err := codec.decode(encoded)
+			if err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+
+			assertJSONEqual(t, body, decoded)
+		})
+	}
+}
+
+func TestAvroCodec_Errors(t *testing.T) {
+	codec, err := newPayloadCodec("", "", filepath.Join("testdata", "order.avsc"))
+	if err != nil {
+		t.Fatalf("creating codec: %v", err)
+	}
+
+	// Union values have to be wrapped in an object keyed by their type.
+	for _, body := range []string{`{"id": "42"}`, `{"id": "42", "status": "SHIPPED", "items": [], "total": 1, "created": 0, "note": null}`,
+		`{"id": "42", "status": "PAID", "items": [], "total": 1, "created": 0, "note": "plain"}`} {
+		if _, err := codec.encode([]byte(body)); err == nil {
+			t.Errorf("expected an error encoding %s", body)
+		}
+	}
+
+	if _, err := codec.decode([]byte{0x02}); err == nil {
+		t.Errorf("expected an error decoding a truncated message")
+	}
+}
+
+func TestNewPayloadCodec(t *testing.T) {
+	protoFile := filepath.Join("testdata", "order.proto")
+	avroSchema := filepath.Join("testdata", "order.avsc")
+
+	tests := []struct {
+		name       string
+		protoFile  string
+		protoType  string
+		avroSchema string
+		wantErr    string
+	}{
+		{name: "none"},
+		{name: "Protobuf and Avro", protoFile: protoFile, protoType: "shop.v1.Order", avroSchema: avroSchema,
+			wantErr: "can't be used together"},
+		{name: "missing type", protoFile: protoFile, wantErr: "--proto-type is required"},
+		{name: "missing file", protoType: "shop.v1.Order", wantErr: "--proto-file is required"},
+		{name: "unknown type", protoFile: protoFile, protoType: "shop.v1.Invoice", wantErr: "finding message type"},
+		{name: "enum type", protoFile: protoFile, protoType: "shop.v1.Order.Status", wantErr: "is not a message type"},
+		{name: "imported type", protoFile: protoFile, protoType: "shop.v1.Money"},
+		{name: "non-existent proto file", protoFile: filepath.Join("testdata", "missing.proto"), protoType: "shop.v1.Order",
+			wantErr: "parsing proto file"},
+		{name: "invalid descriptor set", protoFile: avroSchema, protoType: "shop.v1.Order",
+			wantErr: "parsing descriptor set"},
+		{name: "non-existent Avro schema", avroSchema: filepath.Join("testdata", "missing.avsc"),
+			wantErr: "reading Avro schema"},
+		{name: "invalid Avro schema", avroSchema: filepath.Join("testdata", "order.avro.json"),
+			wantErr: "parsing Avro schema"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			codec, err := newPayloadCodec(test.protoFile, test.protoType, test.avroSchema)
+
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if (codec == nil) != (test.protoFile == "" && test.avroSchema == "") {
+					t.Errorf("unexpected codec %v", codec)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("expected error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
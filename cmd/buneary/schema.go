@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaValidator validates message bodies against JSON Schemas. Schemas are either
+// given explicitly or looked up in the configuration by exchange and routing key.
+// Compiled schemas are cached by their file path.
+type schemaValidator struct {
+	schema  string
+	config  *config
+	schemas map[string]*jsonschema.Schema
+}
+
+// newSchemaValidator creates a validator that uses the given schema file for all
+// messages. If no schema file is given, the schema mappings in the configuration
+// are used instead.
+func newSchemaValidator(schema string, config *config) *schemaValidator {
+	return &schemaValidator{
+		schema:  schema,
+		config:  config,
+		schemas: make(map[string]*jsonschema.Schema),
+	}
+}
+
+// enabled reports whether the validator may validate any messages at all.
+func (s *schemaValidator) enabled() bool {
+	return s.schema != "" || len(s.config.Schemas) > 0
+}
+
+// validate validates the body of the given message. It returns all validation
+// errors, one per violated constraint and prefixed with the location within the
+// body. Messages without a matching schema are considered valid.
+func (s *schemaValidator) validate(message buneary.Message) ([]string, error) {
+	path := s.schema
+	if path == "" {
+		path = s.config.schemaFor(message.Target.Name, message.RoutingKey)
+	}
+
+	if path == "" {
+		return nil, nil
+	}
+
+	schema, ok := s.schemas[path]
+	if !ok {
+		var err error
+		if schema, err = jsonschema.Compile(path); err != nil {
+			return nil, fmt.Errorf("compiling schema %s: %w", path, err)
+		}
+		s.schemas[path] = schema
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(message.Body))
+	decoder.UseNumber()
+
+	var body interface{}
+
+	if err := decoder.Decode(&body); err != nil {
+		return []string{fmt.Sprintf("body is not valid JSON: %s", err)}, nil
+	}
+
+	err := schema.Validate(body)
+
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErrors(validationErr), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("validating message: %w", err)
+	}
+
+	return nil, nil
+}
+
+// validationErrors flattens a tree of validation errors into its leaves, which are
+// the actual violated constraints.
+func validationErrors(err *jsonschema.ValidationError) []string {
+	if len(err.Causes) == 0 {
+		location := err.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		return []string{fmt.Sprintf("%s: %s", location, err.Message)}
+	}
+
+	var errs []string
+
+	for _, cause := range err.Causes {
+		errs = append(errs, validationErrors(cause)...)
+	}
+
+	return errs
+}
+
+// schemaError is returned when a message doesn't conform to its schema.
+type schemaError struct {
+	errs []string
+}
+
+// Error implements the error interface.
+func (e *schemaError) Error() string {
+	return "message does not match the schema:\n  " + strings.Join(e.errs, "\n  ")
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+)
+
+// session is a `buneary shell` session. All commands running within the session share
+// its address, configuration and Provider, so that the credentials are only read in
+// once and the connections to the server are re-used.
+type session struct {
+	address  string
+	config   *buneary.RabbitMQConfig
+	provider buneary.Provider
+}
+
+// sharedProvider wraps a Provider that is shared by multiple commands. Its Close
+// function does nothing, since the Provider is closed by its owner.
+type sharedProvider struct {
+	buneary.Provider
+}
+
+// Close implements buneary.Provider.Close.
+func (sharedProvider) Close() error {
+	return nil
+}
+
+// shellCommand creates the `buneary shell` command, making sure that exactly one
+// argument is passed.
+func shellCommand(options *globalOptions) *cobra.Command {
+	shell := &cobra.Command{
+		Use:   "shell <ADDRESS>",
+		Short: "Start an interactive shell session",
+		Long: `Start an interactive shell session. The credentials are read in once, and all
+commands are run against the server at the given address. Hence, the address has to
+be omitted, e.g. "get queues" instead of "get queues localhost". Flags have to be
+placed after the command name.
+
+In addition to the buneary commands, the following commands are supported:
+
+  use vhost <NAME>   Switch to another virtual host
+  exit, quit         End the session
+
+Hitting Ctrl-C cancels the running command, while Ctrl-D ends the session.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runShell(options, args)
+		},
+	}
+
+	return shell
+}
+
+// runShell starts a shell session for the server at the given address and runs all
+// commands typed in until the user ends the session.
+func runShell(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	config, err := newConfig(options, address)
+	if err != nil {
+		return err
+	}
+
+	s := &session{
+		address:  address,
+		config:   config,
+		provider: buneary.NewProvider(config),
+	}
+
+	defer func() {
+		_ = s.provider.Close()
+	}()
+
+	// Invalid credentials should be reported right away and not by the first command.
+	overview, err := s.provider.GetOverview(options.ctx)
+	if err != nil {
+		return err
+	}
+
+	output := fmt.Sprintf("connected to %s (RabbitMQ %s)\n", overview.ClusterName, overview.RabbitMQVersion)
+	_, _ = options.out.WriteString(output)
+
+	// Ctrl-C should only cancel the running command, which is handled by session.run.
+	signal.Reset(os.Interrupt)
+
+	line := liner.NewLiner()
+
+	defer func() {
+		_ = line.Close()
+	}()
+
+	line.SetCtrlCAborts(true)
+	line.SetCompleter(s.complete)
+
+	historyPath, err := shellHistoryPath()
+	if err == nil {
+		readShellHistory(line, historyPath)
+		defer writeShellHistory(line, historyPath)
+	}
+
+	for {
+		input, err := line.Prompt(s.prompt())
+		switch {
+		case errors.Is(err, liner.ErrPromptAborted):
+			continue
+		case errors.Is(err, io.EOF):
+			_, _ = options.out.WriteString("\n")
+			return nil
+		case err != nil:
+			return fmt.Errorf("reading command: %w", err)
+		}
+
+		words, err := splitWords(input)
+		if err != nil {
+			printShellError(options, err)
+			continue
+		}
+
+		if len(words) == 0 {
+			continue
+		}
+
+		line.AppendHistory(input)
+
+		switch words[0] {
+		case "exit", "quit":
+			return nil
+		case "use":
+			err = s.use(words[1:])
+		default:
+			err = s.run(options.ctx, words)
+		}
+
+		if options.ctx.Err() != nil {
+			return options.ctx.Err()
+		}
+
+		if err != nil {
+			printShellError(options, err)
+		}
+	}
+}
+
+// prompt returns the shell prompt, which contains the address and virtual host.
+func (s *session) prompt() string {
+	vhost := s.config.VirtualHost
+	if vhost == "" {
+		vhost = "/"
+	}
+	return fmt.Sprintf("%s %s> ", s.address, vhost)
+}
+
+// run runs the buneary command consisting of the given words. Hitting Ctrl-C cancels
+// the command, but not the session.
+func (s *session) run(ctx context.Context, words []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	defer signal.Stop(signals)
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	root := rootCommand(s)
+	root.SetArgs(s.withAddress(root, words))
+
+	return root.ExecuteContext(ctx)
+}
+
+// use switches the scope of the session. At the moment, only `use vhost <NAME>` is
+// supported. Connections to the previous virtual host are closed.
+func (s *session) use(args []string) error {
+	if len(args) != 2 || args[0] != "vhost" {
+		return errors.New("usage: use vhost <NAME>")
+	}
+
+	config := *s.config
+	config.VirtualHost = args[1]
+
+	_ = s.provider.Close()
+
+	s.config = &config
+	s.provider = buneary.NewProvider(s.config)
+
+	return nil
+}
+
+// complete returns all completions for the given input. Commands, flags and resource
+// names are completed by the same functions as for the shell completion scripts.
+func (s *session) complete(input string) []string {
+	words, err := splitWords(input)
+	if err != nil {
+		return nil
+	}
+
+	toComplete := ""
+	if len(words) > 0 && !strings.HasSuffix(input, " ") {
+		toComplete = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	prefix := strings.TrimSuffix(input, toComplete)
+
+	var candidates []string
+
+	switch {
+	case len(words) == 0:
+		candidates = append(candidates, "exit", "quit", "use")
+	case len(words) == 1 && words[0] == "use":
+		candidates = append(candidates, "vhost")
+	}
+
+	root := rootCommand(s)
+
+	// The shell's own commands aren't passed to cobra, which would print debug messages
+	// for unknown commands straight to stderr and thus mess up the prompt.
+	if len(words) == 0 || subcommand(root, words[0]) != nil {
+		var buf bytes.Buffer
+
+		root.SetOut(&buf)
+		root.SetErr(ioutil.Discard)
+
+		args := append([]string{cobra.ShellCompNoDescRequestCmd}, s.withAddress(root, words)...)
+		root.SetArgs(append(args, toComplete))
+
+		if err := root.Execute(); err == nil {
+			for _, candidate := range strings.Split(buf.String(), "\n") {
+				// The last line contains the completion directive in the form :4.
+				if candidate != "" && !strings.HasPrefix(candidate, ":") {
+					candidates = append(candidates, candidate)
+				}
+			}
+		}
+	}
+
+	var completions []string
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, toComplete) {
+			completions = append(completions, prefix+candidate)
+		}
+	}
+
+	return completions
+}
+
+// withAddress inserts the session's address into the words of a command right after
+// the command names, provided that the command expects an address.
+func (s *session) withAddress(root *cobra.Command, words []string) []string {
+	cmd := root
+	i := 0
+
+	for ; i < len(words); i++ {
+		sub := subcommand(cmd, words[i])
+		if sub == nil {
+			break
+		}
+		cmd = sub
+	}
+
+	if !strings.Contains(cmd.Use, "<ADDRESS>") {
+		return words
+	}
+
+	withAddress := make([]string, 0, len(words)+1)
+	withAddress = append(withAddress, words[:i]...)
+	withAddress = append(withAddress, s.address)
+
+	return append(withAddress, words[i:]...)
+}
+
+// subcommand returns the sub-command of cmd with the given name or alias, or nil if
+// there is no such sub-command.
+func subcommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name || sub.HasAlias(name) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// splitWords splits a command line into words like a POSIX shell does. Words may be
+// quoted using single or double quotes, and a backslash escapes the next character
+// outside of single quotes.
+func splitWords(line string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape sequence")
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// printShellError prints an error returned by a command without ending the session.
+func printShellError(options *globalOptions, err error) {
+	var exitErr *exitError
+
+	switch {
+	case errors.As(err, &exitErr):
+		// Commands returning an exitError have printed their output already.
+	case errors.Is(err, context.Canceled):
+		_, _ = options.out.WriteString("interrupted\n")
+	default:
+		_, _ = fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// shellHistoryPath returns the path of the shell history, which is stored as
+// buneary/shell_history inside the user's cache directory.
+func shellHistoryPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "buneary", "shell_history"), nil
+}
+
+// readShellHistory reads the shell history from the given file. A missing history is
+// not an error.
+func readShellHistory(line *liner.State, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	_, _ = line.ReadHistory(file)
+}
+
+// writeShellHistory writes the shell history to the given file. Failures are ignored,
+// in which case the history of the session is lost.
+func writeShellHistory(line *liner.State, path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	_, _ = line.WriteHistory(file)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// createShovelOptions defines options for creating a new shovel.
+type createShovelOptions struct {
+	*globalOptions
+	sourceURI              string
+	sourceQueue            string
+	sourceExchange         string
+	sourceExchangeKey      string
+	destinationURI         string
+	destinationQueue       string
+	destinationExchange    string
+	destinationExchangeKey string
+	ackMode                string
+	deleteAfter            string
+	prefetch               int
+}
+
+// createShovelCommand creates the `buneary create shovel` command, making sure that
+// exactly two arguments are passed.
+func createShovelCommand(options *globalOptions) *cobra.Command {
+	createShovelOptions := &createShovelOptions{
+		globalOptions: options,
+	}
+
+	createShovel := &cobra.Command{
+		Use:               "shovel <ADDRESS> <NAME>",
+		Short:             "Create a new dynamic shovel",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCreateShovel(createShovelOptions, args)
+		},
+	}
+
+	createShovel.Flags().
+		StringVar(&createShovelOptions.sourceURI, "src-uri", "amqp://", "the AMQP URI of the source")
+	createShovel.Flags().
+		StringVar(&createShovelOptions.sourceQueue, "src-queue", "", "the queue to consume from")
+	createShovel.Flags().
+		StringVar(&createShovelOptions.sourceExchange, "src-exchange", "", "the exchange to consume from")
+	createShovel.Flags().
+		StringVar(&createShovelOptions.sourceExchangeKey, "src-exchange-key", "", "the routing key for binding to the source exchange")
+	createShovel.Flags().
+		StringVar(&createShovelOptions.destinationURI, "dest-uri", "amqp://", "the AMQP URI of the destination")
+	createShovel.Flags().
+		StringVar(&createShovelOptions.destinationQueue, "dest-queue", "", "the queue to publish to")
+	createShovel.Flags().
+		StringVar(&createShovelOptions.destinationExchange, "dest-exchange", "", "the exchange to publish to")
+	createShovel.Flags().
+		StringVar(&createShovelOptions.destinationExchangeKey, "dest-exchange-key", "", "the routing key for publishing to the destination exchange")
+	createShovel.Flags().
+		StringVar(&createShovelOptions.ackMode, "ack-mode", string(buneary.OnConfirm), "the acknowledgement mode: on-confirm, on-publish or no-ack")
+	createShovel.Flags().
+		StringVar(&createShovelOptions.deleteAfter, "delete-after", "never", "delete the shovel never, after queue-length or after a number of messages")
+	createShovel.Flags().
+		IntVar(&createShovelOptions.prefetch, "prefetch", 0, "the maximum number of unacknowledged messages")
+
+	return createShovel
+}
+
+// runCreateShovel creates a new shovel by reading the command line data, setting the
+// configuration and calling the CreateShovel function. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+func runCreateShovel(options *createShovelOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	if (options.sourceQueue == "") == (options.sourceExchange == "") {
+		return errors.New("either --src-queue or --src-exchange is required")
+	}
+
+	if options.destinationQueue != "" && options.destinationExchange != "" {
+		return errors.New("--dest-queue and --dest-exchange can't be used together")
+	}
+
+	ackMode := buneary.AckMode(options.ackMode)
+
+	if ackMode != buneary.OnConfirm && ackMode != buneary.OnPublish && ackMode != buneary.NoAck {
+		return fmt.Errorf("unsupported ack mode: %s", options.ackMode)
+	}
+
+	if _, err := strconv.Atoi(options.deleteAfter); err != nil &&
+		options.deleteAfter != "never" && options.deleteAfter != "queue-length" {
+		return fmt.Errorf("unsupported delete-after value: %s", options.deleteAfter)
+	}
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	shovel := buneary.Shovel{
+		Name:                   name,
+		SourceURI:              options.sourceURI,
+		SourceQueue:            options.sourceQueue,
+		SourceExchange:         options.sourceExchange,
+		SourceExchangeKey:      options.sourceExchangeKey,
+		DestinationURI:         options.destinationURI,
+		DestinationQueue:       options.destinationQueue,
+		DestinationExchange:    options.destinationExchange,
+		DestinationExchangeKey: options.destinationExchangeKey,
+		AckMode:                ackMode,
+		DeleteAfter:            options.deleteAfter,
+		PrefetchCount:          options.prefetch,
+	}
+
+	if err := provider.CreateShovel(options.ctx, shovel); err != nil {
+		return err
+	}
+
+	_, _ = options.out.WriteString("shovel created successfully\n")
+
+	return nil
+}
+
+// getShovelsCommand creates the `buneary get shovels` command, making sure that
+// exactly one argument is passed.
+func getShovelsCommand(options *globalOptions) *cobra.Command {
+	getShovels := &cobra.Command{
+		Use:               "shovels <ADDRESS>",
+		Short:             "Get all dynamic shovels",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetShovels(options, args)
+		},
+	}
+
+	return getShovels
+}
+
+// getShovelCommand creates the `buneary get shovel` command, making sure that exactly
+// two arguments are passed.
+func getShovelCommand(options *globalOptions) *cobra.Command {
+	getShovel := &cobra.Command{
+		Use:               "shovel <ADDRESS> <NAME>",
+		Short:             "Get a single dynamic shovel",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetShovels(options, args)
+		},
+	}
+
+	return getShovel
+}
+
+// runGetShovels either returns all shovels or - if a shovel name has been specified
+// as second argument - a single shovel. In case the password or both the user and
+// password aren't provided, it will go into interactive mode.
+//
+// The source and destination URIs are omitted from the table since they may contain
+// credentials. They're still included in the JSON output.
+func runGetShovels(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	// The default filter will let pass all shovels regardless of their names.
+	filter := func(_ buneary.Shovel) bool {
+		return true
+	}
+
+	// However, if a shovel name has been specified as second argument, only that
+	// particular shovel should be returned.
+	if len(args) > 1 {
+		filter = func(shovel buneary.Shovel) bool {
+			return shovel.Name == args[1]
+		}
+	}
+
+	shovels, err := provider.GetShovels(options.ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if options.output == outputJSON {
+		return printJSON(options, shovels)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Source", "Destination", "Ack Mode", "State", "Reason"})
+
+	for _, shovel := range shovels {
+		state := shovel.State
+		if state == "" {
+			state = "unknown"
+		}
+
+		row := make([]string, 6)
+		row[0] = shovel.Name
+		row[1] = shovelEndpoint(shovel.SourceQueue, shovel.SourceExchange, shovel.SourceExchangeKey)
+		row[2] = shovelEndpoint(shovel.DestinationQueue, shovel.DestinationExchange, shovel.DestinationExchangeKey)
+		row[3] = string(shovel.AckMode)
+		row[4] = state
+		row[5] = shovel.Reason
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// shovelEndpoint describes the source or destination of a shovel, which is either a
+// queue or an exchange with an optional routing key.
+func shovelEndpoint(queue, exchange, key string) string {
+	switch {
+	case queue != "":
+		return "queue " + queue
+	case exchange != "" && key != "":
+		return fmt.Sprintf("exchange %s (%s)", exchange, key)
+	case exchange != "":
+		return "exchange " + exchange
+	default:
+		return "-"
+	}
+}
+
+// deleteShovelCommand creates the `buneary delete shovel` command, making sure that
+// exactly two arguments are passed.
+func deleteShovelCommand(options *globalOptions) *cobra.Command {
+	deleteShovel := &cobra.Command{
+		Use:               "shovel <ADDRESS> <NAME>",
+		Short:             "Delete a dynamic shovel",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteShovel(options, args)
+		},
+	}
+
+	return deleteShovel
+}
+
+// runDeleteShovel deletes a shovel by reading the command line data, setting the
+// configuration and calling the DeleteShovel function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+func runDeleteShovel(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	provider, err := newProvider(options, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	shovel := buneary.Shovel{
+		Name: name,
+	}
+
+	if err := provider.DeleteShovel(options.ctx, shovel); err != nil {
+		return err
+	}
+
+	_, _ = options.out.WriteString("shovel deleted successfully\n")
+
+	return nil
+}
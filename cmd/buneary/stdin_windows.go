@@ -0,0 +1,16 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// pollStdin waits up to the given timeout for stdin to become readable and reports
+// whether it is. Handles that can't be waited for, like some pipes, are reported as
+// readable, so that they are read right away.
+func pollStdin(timeout time.Duration) (bool, error) {
+	event, _ := windows.WaitForSingleObject(windows.Handle(os.Stdin.Fd()), uint32(timeout/time.Millisecond))
+	return event != uint32(windows.WAIT_TIMEOUT), nil
+}
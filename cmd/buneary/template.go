@@ -0,0 +1,265 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// messageTemplate generates distinct messages from Go templates for the routing key,
+// the header values and the body. Each message is generated with its own sequence
+// number, starting at 1, and its own row of the data file if there is one.
+type messageTemplate struct {
+	routingKey *template.Template
+	headers    map[string]*template.Template
+	body       *template.Template
+	rows       []map[string]interface{}
+	random     *mathrand.Rand
+	seq        int
+}
+
+// templateData is the data passed to the templates, so that the sequence number and
+// the current row are available as {{ .Seq }} and {{ .Row.<column> }}.
+type templateData struct {
+	Seq int
+	Row map[string]interface{}
+}
+
+// newMessageTemplate parses the given templates and reads the rows of the data file,
+// which is either a CSV file with a header row or a JSON file containing an array
+// of objects. The data file is optional.
+//
+// The headers are given in the form key1=val1,key2=val2, where each value is parsed
+// as a separate template. Hence, both the template actions and the rendered values
+// may contain commas and equal signs.
+func newMessageTemplate(routingKey, headers, body, dataFile string) (*messageTemplate, error) {
+	m := &messageTemplate{
+		random: mathrand.New(mathrand.NewSource(time.Now().UnixNano())),
+	}
+
+	if dataFile != "" {
+		rows, err := readDataFile(dataFile)
+		if err != nil {
+			return nil, err
+		}
+		m.rows = rows
+	}
+
+	funcs := template.FuncMap{
+		"seq":        func() int { return m.seq },
+		"uuid":       newUUID,
+		"now":        time.Now,
+		"timestamp":  func() string { return time.Now().UTC().Format(time.RFC3339Nano) },
+		"unix":       func() int64 { return time.Now().Unix() },
+		"randInt":    m.randInt,
+		"randString": m.randString,
+		"data":       m.data,
+	}
+
+	var err error
+
+	if m.routingKey, err = template.New("routing key").Funcs(funcs).Parse(routingKey); err != nil {
+		return nil, fmt.Errorf("parsing routing key template: %w", err)
+	}
+
+	sources, err := splitHeaderTemplates(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	m.headers = make(map[string]*template.Template, len(sources))
+
+	for key, source := range sources {
+		if m.headers[key], err = template.New("header " + key).Funcs(funcs).Parse(source); err != nil {
+			return nil, fmt.Errorf("parsing template of header %s: %w", key, err)
+		}
+	}
+
+	if m.body, err = template.New("body").Funcs(funcs).Parse(body); err != nil {
+		return nil, fmt.Errorf("parsing body template: %w", err)
+	}
+
+	return m, nil
+}
+
+// execute renders the routing key, the header values and the body for the message
+// with the given sequence number. The data file rows are used in turns.
+func (m *messageTemplate) execute(seq int) (routingKey string, headers map[string]interface{}, body string, err error) {
+	m.seq = seq
+
+	data := templateData{
+		Seq: seq,
+	}
+
+	if len(m.rows) > 0 {
+		data.Row = m.rows[(seq-1)%len(m.rows)]
+	}
+
+	render := func(t *template.Template) (string, error) {
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("executing %s template: %w", t.Name(), err)
+		}
+		return b.String(), nil
+	}
+
+	if routingKey, err = render(m.routingKey); err != nil {
+		return "", nil, "", err
+	}
+
+	headers = make(map[string]interface{}, len(m.headers))
+
+	for key, t := range m.headers {
+		if headers[key], err = render(t); err != nil {
+			return "", nil, "", err
+		}
+	}
+
+	if body, err = render(m.body); err != nil {
+		return "", nil, "", err
+	}
+
+	return routingKey, headers, body, nil
+}
+
+// splitHeaderTemplates splits header templates in the form key1=val1,key2=val2 into
+// the keys and the value templates. Unlike parseHeaders, commas and equal signs within
+// template actions like {{ printf "a=%d" 1 }} don't separate headers or keys.
+func splitHeaderTemplates(source string) (map[string]string, error) {
+	headers := make(map[string]string)
+
+	if source == "" {
+		return headers, nil
+	}
+
+	var (
+		start    int
+		equals   = -1
+		inAction bool
+	)
+
+	for i := 0; i <= len(source); i++ {
+		switch {
+		case i == len(source) || (!inAction && source[i] == ','):
+			if equals < 0 {
+				return nil, errors.New("expected header in form key=value")
+			}
+			headers[strings.TrimSpace(source[start:equals])] = strings.TrimSpace(source[equals+1 : i])
+			start, equals = i+1, -1
+		case strings.HasPrefix(source[i:], "{{"):
+			inAction = true
+			i++
+		case strings.HasPrefix(source[i:], "}}"):
+			inAction = false
+			i++
+		case !inAction && source[i] == '=' && equals < 0:
+			equals = i
+		}
+	}
+
+	return headers, nil
+}
+
+// randInt returns a random integer in the interval [min, max].
+func (m *messageTemplate) randInt(min, max int) (int, error) {
+	if max < min {
+		return 0, fmt.Errorf("randInt: %d is less than %d", max, min)
+	}
+	return min + m.random.Intn(max-min+1), nil
+}
+
+// randString returns a random alphanumeric string of the given length.
+func (m *messageTemplate) randString(length int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = letters[m.random.Intn(len(letters))]
+	}
+
+	return string(b)
+}
+
+// data returns the value of the given column in the current row of the data file.
+func (m *messageTemplate) data(column string) (interface{}, error) {
+	if len(m.rows) == 0 {
+		return nil, errors.New("data: no data file has been provided")
+	}
+
+	value, ok := m.rows[(m.seq-1)%len(m.rows)][column]
+	if !ok {
+		return nil, fmt.Errorf("data: unknown column %s", column)
+	}
+
+	return value, nil
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	var u [16]byte
+
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", fmt.Errorf("generating UUID: %w", err)
+	}
+
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]), nil
+}
+
+// readDataFile reads the rows of a CSV or JSON data file, depending on the file
+// extension. CSV values are strings, whereas JSON values keep their types.
+func readDataFile(path string) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading data file: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, fmt.Errorf("parsing data file: %w", err)
+		}
+
+		return rows, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading data file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing data file: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(record))
+		for i, column := range records[0] {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
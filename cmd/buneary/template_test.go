@@ -0,0 +1,255 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// writeDataFile writes the given content into a data file with the given name in a
+// temporary directory and returns its path.
+func writeDataFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "buneary")
+	if err != nil {
+		t.Fatalf("creating directory: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	path := filepath.Join(dir, name)
+
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing data file: %v", err)
+	}
+
+	return path
+}
+
+func TestMessageTemplate_Execute(t *testing.T) {
+	csvFile := writeDataFile(t, "customers.csv", "name,region\nacme,eu\nglobex,us\n")
+	jsonFile := writeDataFile(t, "customers.json", `[{"name": "acme", "amount": 100}, {"name": "globex", "amount": 2.5}]`)
+
+	type message struct {
+		routingKey string
+		headers    map[string]interface{}
+		body       string
+	}
+
+	tests := []struct {
+		name       string
+		routingKey string
+		headers    string
+		body       string
+		dataFile   string
+		want       []message
+	}{
+		{
+			name:       "static",
+			routingKey: "orders",
+			body:       "hello",
+			want: []message{
+				{routingKey: "orders", headers: map[string]interface{}{}, body: "hello"},
+			},
+		},
+		{
+			name:       "sequence numbers",
+			routingKey: "orders.{{ seq }}",
+			headers:    "seq={{ .Seq }}",
+			body:       `{"id": {{ seq }}}`,
+			want: []message{
+				{routingKey: "orders.1", headers: map[string]interface{}{"seq": "1"}, body: `{"id": 1}`},
+				{routingKey: "orders.2", headers: map[string]interface{}{"seq": "2"}, body: `{"id": 2}`},
+				{routingKey: "orders.3", headers: map[string]interface{}{"seq": "3"}, body: `{"id": 3}`},
+			},
+		},
+		{
+			name:       "CSV rows in turns",
+			routingKey: `orders.{{ data "region" }}`,
+			headers:    "customer={{ .Row.name }}",
+			body:       `{{ data "name" }}`,
+			dataFile:   csvFile,
+			want: []message{
+				{routingKey: "orders.eu", headers: map[string]interface{}{"customer": "acme"}, body: "acme"},
+				{routingKey: "orders.us", headers: map[string]interface{}{"customer": "globex"}, body: "globex"},
+				{routingKey: "orders.eu", headers: map[string]interface{}{"customer": "acme"}, body: "acme"},
+			},
+		},
+		{
+			name:       "JSON rows",
+			routingKey: "orders",
+			body:       `{{ .Row.name }}: {{ data "amount" }}`,
+			dataFile:   jsonFile,
+			want: []message{
+				{routingKey: "orders", headers: map[string]interface{}{}, body: "acme: 100"},
+				{routingKey: "orders", headers: map[string]interface{}{}, body: "globex: 2.5"},
+			},
+		},
+		{
+			name:       "header values with commas and equal signs",
+			routingKey: "orders",
+			headers:    `filter={{ printf "region=%s,tier=%d" "eu" seq }},tenant=acme`,
+			body:       "hello",
+			want: []message{
+				{routingKey: "orders", headers: map[string]interface{}{"filter": "region=eu,tier=1", "tenant": "acme"}, body: "hello"},
+				{routingKey: "orders", headers: map[string]interface{}{"filter": "region=eu,tier=2", "tenant": "acme"}, body: "hello"},
+			},
+		},
+		{
+			name:       "empty header value",
+			routingKey: "orders",
+			headers:    `tenant={{ if false }}acme{{ end }}`,
+			body:       "hello",
+			want: []message{
+				{routingKey: "orders", headers: map[string]interface{}{"tenant": ""}, body: "hello"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := newMessageTemplate(test.routingKey, test.headers, test.body, test.dataFile)
+			if err != nil {
+				t.Fatalf("parsing templates: %v", err)
+			}
+
+			for i, want := range test.want {
+				routingKey, headers, body, err := tmpl.execute(i + 1)
+				if err != nil {
+					t.Fatalf("executing templates for message %d: %v", i+1, err)
+				}
+
+				got := message{routingKey: routingKey, headers: headers, body: body}
+
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("expected message %d to be %+v, got %+v", i+1, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageTemplate_Helpers(t *testing.T) {
+	tmpl, err := newMessageTemplate(`{{ uuid }}`, "", `{{ randInt 5 7 }} {{ randString 12 }} {{ unix }}`, "")
+	if err != nil {
+		t.Fatalf("parsing templates: %v", err)
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	body := regexp.MustCompile(`^[5-7] [a-zA-Z0-9]{12} [0-9]+$`)
+	seen := make(map[string]bool)
+
+	for i := 1; i <= 100; i++ {
+		routingKey, _, b, err := tmpl.execute(i)
+		if err != nil {
+			t.Fatalf("executing templates: %v", err)
+		}
+
+		if !uuid.MatchString(routingKey) {
+			t.Errorf("expected a version 4 UUID, got %s", routingKey)
+		}
+		if seen[routingKey] {
+			t.Errorf("expected distinct UUIDs, got %s twice", routingKey)
+		}
+		if !body.MatchString(b) {
+			t.Errorf("expected random values in range, got %s", b)
+		}
+
+		seen[routingKey] = true
+	}
+}
+
+func TestMessageTemplate_Errors(t *testing.T) {
+	csvFile := writeDataFile(t, "customers.csv", "name\nacme\n")
+
+	tests := []struct {
+		name       string
+		routingKey string
+		headers    string
+		body       string
+		dataFile   string
+		wantErr    string
+	}{
+		{
+			name:    "header without value",
+			headers: "tenant",
+			wantErr: "expected header in form key=value",
+		},
+		{
+			name:    "invalid header template",
+			headers: "tenant={{ .Row.name",
+			wantErr: "parsing template of header tenant",
+		},
+		{
+			name:       "invalid routing key template",
+			routingKey: "{{ seq",
+			wantErr:    "parsing routing key template",
+		},
+		{
+			name:    "missing data file",
+			body:    "{{ data \"name\" }}",
+			wantErr: "data: no data file has been provided",
+		},
+		{
+			name:     "unknown column",
+			headers:  "region={{ data \"region\" }}",
+			dataFile: csvFile,
+			wantErr:  "executing header region template: template: header region:1:3: executing \"header region\" at <data \"region\">: error calling data: data: unknown column region",
+		},
+		{
+			name:    "invalid range",
+			body:    "{{ randInt 2 1 }}",
+			wantErr: "randInt: 1 is less than 2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := newMessageTemplate(test.routingKey, test.headers, test.body, test.dataFile)
+			if err == nil {
+				_, _, _, err = tmpl.execute(1)
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("expected error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSplitHeaderTemplates(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{source: "", want: map[string]string{}},
+		{source: "a=1, b = 2", want: map[string]string{"a": "1", "b": "2"}},
+		{source: "a=1,a=2", want: map[string]string{"a": "2"}},
+		{source: "a=", want: map[string]string{"a": ""}},
+		{source: `a={{ printf "x=%d,y" 1 }},b={{ seq }}`, want: map[string]string{"a": `{{ printf "x=%d,y" 1 }}`, "b": "{{ seq }}"}},
+		{source: "a={{ seq }}-{{ .Seq }}", want: map[string]string{"a": "{{ seq }}-{{ .Seq }}"}},
+		{source: "a", wantErr: true},
+		{source: "a=1,", wantErr: true},
+		{source: `{{ "a=1" }}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			headers, err := splitHeaderTemplates(test.source)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+			if !test.wantErr && !reflect.DeepEqual(headers, test.want) {
+				t.Errorf("expected %v, got %v", test.want, headers)
+			}
+		})
+	}
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dominikbraun/buneary/pkg/buneary"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// topColumn represents a column of the `buneary top` dashboard. Each column knows
+// how to render and how to compare queues, which makes all columns sortable.
+type topColumn struct {
+	name  string
+	value func(queue buneary.Queue, delta int) string
+	less  func(a, b buneary.Queue, deltaA, deltaB int) bool
+}
+
+// topColumns lists all dashboard columns in display order. The keys 1 to 8 select
+// the column to sort by, starting with the name column.
+var topColumns = []topColumn{
+	{
+		name:  "Name",
+		value: func(q buneary.Queue, _ int) string { return q.Name },
+		less:  func(a, b buneary.Queue, _, _ int) bool { return a.Name < b.Name },
+	},
+	{
+		name:  "Messages",
+		value: func(q buneary.Queue, _ int) string { return strconv.Itoa(q.Messages) },
+		less:  func(a, b buneary.Queue, _, _ int) bool { return a.Messages < b.Messages },
+	},
+	{
+		name:  "Ready",
+		value: func(q buneary.Queue, _ int) string { return strconv.Itoa(q.MessagesReady) },
+		less:  func(a, b buneary.Queue, _, _ int) bool { return a.MessagesReady < b.MessagesReady },
+	},
+	{
+		name:  "Unacked",
+		value: func(q buneary.Queue, _ int) string { return strconv.Itoa(q.MessagesUnacknowledged) },
+		less:  func(a, b buneary.Queue, _, _ int) bool { return a.MessagesUnacknowledged < b.MessagesUnacknowledged },
+	},
+	{
+		name:  "Consumers",
+		value: func(q buneary.Queue, _ int) string { return strconv.Itoa(q.Consumers) },
+		less:  func(a, b buneary.Queue, _, _ int) bool { return a.Consumers < b.Consumers },
+	},
+	{
+		name:  "Publish/s",
+		value: func(q buneary.Queue, _ int) string { return rateToString(q.MessageStats.PublishRate) },
+		less: func(a, b buneary.Queue, _, _ int) bool {
+			return a.MessageStats.PublishRate < b.MessageStats.PublishRate
+		},
+	},
+	{
+		name:  "Deliver/s",
+		value: func(q buneary.Queue, _ int) string { return rateToString(q.MessageStats.DeliverRate) },
+		less: func(a, b buneary.Queue, _, _ int) bool {
+			return a.MessageStats.DeliverRate < b.MessageStats.DeliverRate
+		},
+	},
+	{
+		name:  "Trend",
+		value: func(_ buneary.Queue, d int) string { return fmt.Sprintf("%+d", d) },
+		less:  func(_, _ buneary.Queue, dA, dB int) bool { return dA < dB },
+	},
+}
+
+const (
+	// topNumericWidth is the width of all columns except for the name column.
+	topNumericWidth = 10
+
+	// topMinNameWidth is the minimum width of the name column.
+	topMinNameWidth = 16
+)
+
+// topOptions defines options for running the dashboard.
+type topOptions struct {
+	*globalOptions
+	interval time.Duration
+	sortBy   string
+	filter   string
+}
+
+// topCommand creates the `buneary top` command, making sure that exactly one argument
+// is passed.
+func topCommand(options *globalOptions) *cobra.Command {
+	topOptions := &topOptions{
+		globalOptions: options,
+	}
+
+	top := &cobra.Command{
+		Use:   "top <ADDRESS>",
+		Short: "Watch queue depths, rates and consumers in a live dashboard",
+		Long: `Watch queue depths, rates and consumers in a live dashboard that refreshes at the
+given interval. Queues whose backlog has grown since the last refresh are highlighted.
+
+Keys: q quit, 1-8 sort by column, s next sort column, r reverse order, / filter by name.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(options, completeAddresses),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTop(topOptions, args)
+		},
+	}
+
+	top.Flags().
+		DurationVar(&topOptions.interval, "interval", 2*time.Second, "the refresh interval")
+	top.Flags().
+		StringVar(&topOptions.sortBy, "sort", "ready", "the column to sort by")
+	top.Flags().
+		StringVar(&topOptions.filter, "filter", "", "only show queues whose name contains the filter")
+
+	return top
+}
+
+// topState holds the current state of the dashboard, i.e. the latest queues, the
+// backlog growth since the previous refresh and the user's display settings.
+type topState struct {
+	address    string
+	queues     []buneary.Queue
+	ready      map[string]int
+	deltas     map[string]int
+	sortBy     int
+	descending bool
+	filter     string
+	editing    bool
+	err        error
+	refreshed  time.Time
+}
+
+// runTop runs the dashboard until the user quits. In case the password or both the
+// user and password aren't provided, it will ask for them before entering the full-
+// screen mode.
+//
+// The terminal is switched into raw mode so that single key presses can be handled
+// immediately. The original terminal state is restored on exit.
+func runTop(options *topOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	fd := int(os.Stdin.Fd())
+
+	if !terminal.IsTerminal(fd) {
+		return errors.New("buneary top requires an interactive terminal")
+	}
+
+	if options.interval <= 0 {
+		return errors.New("the refresh interval has to be positive")
+	}
+
+	state := &topState{
+		address: address,
+		ready:   make(map[string]int),
+		deltas:  make(map[string]int),
+		sortBy:  -1,
+		filter:  options.filter,
+	}
+
+	for i, column := range topColumns {
+		if strings.EqualFold(column.name, options.sortBy) {
+			state.selectColumn(i)
+		}
+	}
+
+	if state.sortBy < 0 {
+		return fmt.Errorf("unknown sort column: %s", options.sortBy)
+	}
+
+	provider, err := newProvider(options.globalOptions, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = provider.Close()
+	}()
+
+	oldState, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("switching terminal to raw mode: %w", err)
+	}
+
+	defer func() {
+		_ = terminal.Restore(fd, oldState)
+	}()
+
+	// Switch to the alternate screen buffer and hide the cursor, restoring both
+	// once the dashboard is closed.
+	_, _ = options.out.WriteString("\033[?1049h\033[?25l")
+
+	defer func() {
+		_, _ = options.out.WriteString("\033[?25h\033[?1049l")
+	}()
+
+	keys := make(chan byte)
+	keysCtx, stopKeys := context.WithCancel(options.ctx)
+
+	go func() {
+		defer close(keys)
+
+		buf := make([]byte, 1)
+
+		for {
+			if err := waitForStdin(keysCtx); err != nil {
+				return
+			}
+			if _, err := os.Stdin.Read(buf); err != nil {
+				return
+			}
+			select {
+			case keys <- buf[0]:
+			case <-keysCtx.Done():
+				return
+			}
+		}
+	}()
+
+	// The key reader has to be stopped before the terminal is restored, so that it
+	// doesn't read any input meant for the shell or another program.
+	defer func() {
+		stopKeys()
+		for range keys {
+		}
+	}()
+
+	ticker := time.NewTicker(options.interval)
+	defer ticker.Stop()
+
+	state.refresh(options.ctx, provider)
+
+	for {
+		width, height, err := terminal.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+
+		_, _ = options.out.WriteString(state.render(width, height))
+
+		select {
+		case <-ticker.C:
+			state.refresh(options.ctx, provider)
+		case key, ok := <-keys:
+			if !ok || state.handleKey(key) {
+				return nil
+			}
+		case <-options.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// refresh reads all queues from the server and computes the growth of each queue's
+// backlog compared to the previous refresh. Errors are kept for display.
+func (t *topState) refresh(ctx context.Context, provider buneary.Provider) {
+	queues, err := provider.GetQueues(ctx, func(_ buneary.Queue) bool {
+		return true
+	})
+
+	t.err = err
+	if err != nil {
+		return
+	}
+
+	deltas := make(map[string]int, len(queues))
+	ready := make(map[string]int, len(queues))
+
+	for _, queue := range queues {
+		if previous, ok := t.ready[queue.Name]; ok {
+			deltas[queue.Name] = queue.MessagesReady - previous
+		}
+		ready[queue.Name] = queue.MessagesReady
+	}
+
+	t.queues = queues
+	t.ready = ready
+	t.deltas = deltas
+	t.refreshed = time.Now()
+}
+
+// handleKey processes a single key press and reports whether the user wants to quit.
+// While editing the filter, all printable keys are appended to the filter instead.
+func (t *topState) handleKey(key byte) bool {
+	const (
+		ctrlC     = 3
+		enter     = 13
+		escape    = 27
+		backspace = 127
+	)
+
+	if t.editing {
+		switch {
+		case key == enter || key == escape:
+			t.editing = false
+		case key == backspace && len(t.filter) > 0:
+			t.filter = t.filter[:len(t.filter)-1]
+		case key == ctrlC:
+			return true
+		case key >= 32 && key < backspace:
+			t.filter += string(key)
+		}
+		return false
+	}
+
+	switch {
+	case key == 'q' || key == ctrlC:
+		return true
+	case key >= '1' && int(key-'1') < len(topColumns):
+		t.selectColumn(int(key - '1'))
+	case key == 's':
+		t.selectColumn((t.sortBy + 1) % len(topColumns))
+	case key == 'r':
+		t.descending = !t.descending
+	case key == '/':
+		t.editing = true
+		t.filter = ""
+	}
+
+	return false
+}
+
+// selectColumn sorts by the given column. The name column is sorted in ascending
+// order while all other columns are sorted in descending order by default.
+func (t *topState) selectColumn(column int) {
+	t.sortBy = column
+	t.descending = column != 0
+}
+
+// render returns the entire screen content for the given terminal size, starting
+// with escape codes that move the cursor home and clear the screen.
+func (t *topState) render(width, height int) string {
+	var queues []buneary.Queue
+
+	for _, queue := range t.queues {
+		if strings.Contains(queue.Name, t.filter) {
+			queues = append(queues, queue)
+		}
+	}
+
+	column := topColumns[t.sortBy]
+
+	sort.SliceStable(queues, func(i, j int) bool {
+		a, b := queues[i], queues[j]
+		if t.descending {
+			return column.less(b, a, t.deltas[b.Name], t.deltas[a.Name])
+		}
+		return column.less(a, b, t.deltas[a.Name], t.deltas[b.Name])
+	})
+
+	nameWidth := width - (len(topColumns)-1)*(topNumericWidth+1) - 1
+	if nameWidth < topMinNameWidth {
+		nameWidth = topMinNameWidth
+	}
+
+	var lines []string
+
+	status := truncate(fmt.Sprintf("buneary top - %s - %d queues - refreshed %s",
+		t.address, len(queues), t.refreshed.Format("15:04:05")), width)
+
+	// The error is truncated before colouring it, so that the reset code isn't cut off.
+	if t.err != nil {
+		prefix := truncate(fmt.Sprintf("buneary top - %s - ", t.address), width)
+		message := truncate(fmt.Sprintf("error: %s", t.err), width-len([]rune(prefix)))
+		status = prefix
+		if message != "" {
+			status += "\033[31m" + message + "\033[0m"
+		}
+	}
+
+	help := "q quit  1-8/s sort  r reverse  / filter"
+
+	switch {
+	case t.editing:
+		help = fmt.Sprintf("filter: %s_", t.filter)
+	case t.filter != "":
+		help += fmt.Sprintf("  (filter: %s)", t.filter)
+	}
+
+	lines = append(lines, status, truncate(help, width))
+
+	header := make([]string, len(topColumns))
+
+	for i, c := range topColumns {
+		name := c.name
+		if i == t.sortBy {
+			if t.descending {
+				name += "▼"
+			} else {
+				name += "▲"
+			}
+		}
+		header[i] = name
+	}
+
+	lines = append(lines, "\033[7m"+t.formatRow(header, nameWidth, width)+"\033[0m")
+
+	for _, queue := range queues {
+		if len(lines) >= height {
+			break
+		}
+
+		delta, known := t.deltas[queue.Name]
+
+		row := make([]string, len(topColumns))
+		for i, c := range topColumns {
+			row[i] = c.value(queue, delta)
+		}
+
+		if !known {
+			row[len(row)-1] = "-"
+		}
+
+		line := t.formatRow(row, nameWidth, width)
+
+		// Highlight all queues whose backlog has grown since the last refresh.
+		if delta > 0 {
+			line = "\033[31m" + line + "\033[0m"
+		}
+
+		lines = append(lines, line)
+	}
+
+	return "\033[H\033[2J" + strings.Join(lines, "\r\n")
+}
+
+// formatRow aligns the given cells to the column widths: The name column is left-
+// aligned, all other columns are right-aligned.
+func (t *topState) formatRow(cells []string, nameWidth, width int) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("%-*s", nameWidth, truncate(cells[0], nameWidth)))
+
+	for _, cell := range cells[1:] {
+		b.WriteString(fmt.Sprintf(" %*s", topNumericWidth, truncate(cell, topNumericWidth)))
+	}
+
+	return truncate(b.String(), width)
+}
+
+// truncate shortens the given string to at most max runes.
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
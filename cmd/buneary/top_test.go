@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// ansiCodes matches the escape sequences used by `buneary top`.
+var ansiCodes = regexp.MustCompile("\033\\[[0-9;]*[A-Za-z]")
+
+func TestTopState_RenderError(t *testing.T) {
+	state := &topState{
+		address: "localhost",
+		err:     errors.New("dialling RabbitMQ server: connection refused"),
+	}
+
+	for _, width := range []int{10, 21, 30, 60, 200} {
+		output := strings.TrimPrefix(state.render(width, 10), "\033[H\033[2J")
+		status := strings.Split(output, "\r\n")[0]
+
+		if visible := ansiCodes.ReplaceAllString(status, ""); len([]rune(visible)) > width {
+			t.Errorf("expected status %q to fit into %d columns", visible, width)
+		}
+
+		// The colour has to be reset even if the error has been truncated.
+		if strings.Contains(status, "\033[31m") && !strings.HasSuffix(status, "\033[0m") {
+			t.Errorf("expected status %q to reset the colour", status)
+		}
+	}
+
+	status := strings.Split(state.render(200, 10), "\r\n")[0]
+
+	if !strings.HasSuffix(status, "\033[31merror: dialling RabbitMQ server: connection refused\033[0m") {
+		t.Errorf("expected status %q to contain the complete error", status)
+	}
+}
@@ -3,9 +3,16 @@ module github.com/dominikbraun/buneary
 go 1.14
 
 require (
+	github.com/jhump/protoreflect v1.12.0
+	github.com/klauspost/compress v1.15.1
+	github.com/linkedin/goavro/v2 v2.11.1
 	github.com/michaelklishin/rabbit-hole/v2 v2.6.0
 	github.com/olekukonko/tablewriter v0.0.4
+	github.com/peterh/liner v1.2.2
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.0
 	github.com/spf13/cobra v1.1.1
 	github.com/streadway/amqp v1.0.0
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/sys v0.0.0-20211117180635-dee7805ff2e1
+	google.golang.org/protobuf v1.26.0
 )
@@ -1,11 +0,0 @@
-package main
-
-import (
-	"log"
-)
-
-func main() {
-	if err := rootCommand().Execute(); err != nil {
-		log.Fatal(err)
-	}
-}
@@ -0,0 +1,226 @@
+package buneary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+)
+
+// ErrDryRun is returned by functions that would change the state of the server but
+// don't support dry-run mode, e.g. CreateShovel or GetMessages without requeueing.
+var ErrDryRun = errors.New("not supported in dry-run mode")
+
+// dryRunCreateExchange describes the request for creating the given exchange. An
+// existing exchange with different properties is reported as an error, since the
+// actual request would fail for the same reason.
+func (b *buneary) dryRunCreateExchange(ctx context.Context, exchange Exchange) error {
+	if err := b.setupClient(ctx); err != nil {
+		return err
+	}
+
+	existing, err := b.client.GetExchange(b.config.vhost(), exchange.Name)
+	err = httpError(err)
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("getting exchange: %w", err)
+	case existing.Type != string(exchange.Type) || existing.Durable != exchange.Durable || existing.AutoDelete != exchange.AutoDelete:
+		return fmt.Errorf("declaring exchange: %w: exchange %s has type %s, durable=%t and auto-delete=%t",
+			ErrAlreadyExistsWithDifferentProperties, exchange.Name, existing.Type, existing.Durable, existing.AutoDelete)
+	}
+
+	return b.describeRequest(http.MethodPut, "exchanges", exchange.Name, exchangeSettings(exchange))
+}
+
+// dryRunCreateQueue describes the request for creating the given queue. Just like in
+// dryRunCreateExchange, an existing queue with different properties is an error.
+func (b *buneary) dryRunCreateQueue(ctx context.Context, queue Queue) error {
+	if err := b.setupClient(ctx); err != nil {
+		return err
+	}
+
+	existing, err := b.client.GetQueue(b.config.vhost(), queue.Name)
+	err = httpError(err)
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("getting queue: %w", err)
+	default:
+		// Classic queues may lack the x-queue-type argument.
+		existingType, _ := existing.Arguments["x-queue-type"].(string)
+		if existingType == "" {
+			existingType = string(Classic)
+		}
+
+		if existingType != string(queue.Type) || existing.Durable != queue.Durable || existing.AutoDelete != queue.AutoDelete {
+			return fmt.Errorf("declaring queue: %w: queue %s has type %s, durable=%t and auto-delete=%t",
+				ErrAlreadyExistsWithDifferentProperties, queue.Name, existingType, existing.Durable, existing.AutoDelete)
+		}
+	}
+
+	return b.describeRequest(http.MethodPut, "queues", queue.Name, queueSettings(queue))
+}
+
+// dryRunCreateBinding describes the request for creating the given binding after
+// making sure that its source and target exist.
+func (b *buneary) dryRunCreateBinding(ctx context.Context, binding Binding) error {
+	if err := b.setupClient(ctx); err != nil {
+		return err
+	}
+
+	if _, err := b.client.GetExchange(b.config.vhost(), binding.From.Name); err != nil {
+		return fmt.Errorf("getting source exchange: %w", httpError(err))
+	}
+
+	var err error
+
+	switch binding.Type {
+	case ToExchange:
+		_, err = b.client.GetExchange(b.config.vhost(), binding.TargetName)
+	default:
+		_, err = b.client.GetQueue(b.config.vhost(), binding.TargetName)
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting target %s: %w", binding.Type, httpError(err))
+	}
+
+	info := bindingInfo(b.config.vhost(), binding)
+
+	targetType := "q"
+	if binding.Type == ToExchange {
+		targetType = "e"
+	}
+
+	path := fmt.Sprintf("/api/bindings/%s/e/%s/%s/%s", url.PathEscape(b.config.vhost()),
+		url.PathEscape(binding.From.Name), targetType, url.PathEscape(binding.TargetName))
+
+	return b.describe(http.MethodPost, path, info)
+}
+
+// dryRunDelete describes the request for deleting the exchange or queue with the given
+// name, which is specified by kind. The resource has to exist.
+func (b *buneary) dryRunDelete(ctx context.Context, kind, name string) error {
+	if err := b.setupClient(ctx); err != nil {
+		return err
+	}
+
+	var err error
+
+	switch kind {
+	case "exchanges":
+		_, err = b.client.GetExchange(b.config.vhost(), name)
+	case "queues":
+		_, err = b.client.GetQueue(b.config.vhost(), name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting %s: %w", kind[:len(kind)-1], httpError(err))
+	}
+
+	return b.describeRequest(http.MethodDelete, kind, name, nil)
+}
+
+// dryRunPublish describes the AMQP operations for publishing the given messages after
+// making sure that their exchanges exist. The default exchange always exists.
+func (b *buneary) dryRunPublish(ctx context.Context, messages []Message) error {
+	if err := b.setupClient(ctx); err != nil {
+		return err
+	}
+
+	checked := make(map[string]bool)
+
+	for _, message := range messages {
+		exchange := message.Target.Name
+		if exchange == "" || checked[exchange] {
+			continue
+		}
+
+		if _, err := b.client.GetExchange(b.config.vhost(), exchange); err != nil {
+			return fmt.Errorf("getting exchange: %w", httpError(err))
+		}
+
+		checked[exchange] = true
+	}
+
+	for _, message := range messages {
+		exchange, key, mandatory, _, publishing := messageArgs(message)
+
+		b.write("AMQP basic.publish exchange=%q routing_key=%q mandatory=%t content_type=%q body=%d bytes",
+			exchange, key, mandatory, publishing.ContentType, len(publishing.Body))
+	}
+
+	return nil
+}
+
+// describeRequest describes an HTTP request for the resource of the given kind, e.g.
+// exchanges, with the given name in the configured virtual host.
+func (b *buneary) describeRequest(method, kind, name string, body interface{}) error {
+	path := fmt.Sprintf("/api/%s/%s/%s", kind, url.PathEscape(b.config.vhost()), url.PathEscape(name))
+	return b.describe(method, path, body)
+}
+
+// describe writes an HTTP request with the given method, path and JSON body to the
+// dry-run output. A nil body is omitted.
+func (b *buneary) describe(method, path string, body interface{}) error {
+	if body == nil {
+		b.write("HTTP %s %s", method, path)
+		return nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding request body: %w", err)
+	}
+
+	b.write("HTTP %s %s %s", method, path, data)
+
+	return nil
+}
+
+// write writes a line to the dry-run output, if there is one.
+func (b *buneary) write(format string, args ...interface{}) {
+	if b.config.DryRunOutput == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(b.config.DryRunOutput, format+"\n", args...)
+}
+
+// exchangeSettings returns the settings sent to the HTTP API for creating the given
+// exchange.
+func exchangeSettings(exchange Exchange) rabbithole.ExchangeSettings {
+	return rabbithole.ExchangeSettings{
+		Type:       string(exchange.Type),
+		Durable:    exchange.Durable,
+		AutoDelete: exchange.AutoDelete,
+	}
+}
+
+// queueSettings returns the settings sent to the HTTP API for creating the given queue.
+func queueSettings(queue Queue) rabbithole.QueueSettings {
+	return rabbithole.QueueSettings{
+		Type:       string(queue.Type),
+		Durable:    queue.Durable,
+		AutoDelete: queue.AutoDelete,
+	}
+}
+
+// bindingInfo returns the binding sent to the HTTP API for creating the given binding
+// in the given virtual host.
+func bindingInfo(vhost string, binding Binding) rabbithole.BindingInfo {
+	return rabbithole.BindingInfo{
+		Source:          binding.From.Name,
+		Vhost:           vhost,
+		Destination:     binding.TargetName,
+		DestinationType: string(binding.Type),
+		RoutingKey:      binding.Key,
+		Arguments:       map[string]interface{}{},
+	}
+}
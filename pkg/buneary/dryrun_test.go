@@ -0,0 +1,262 @@
+package buneary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newDryRunServer starts a server serving the given JSON responses for GET requests
+// by their escaped path. All other paths result in 404 Not Found. The test fails if
+// the server receives a request other than GET, which would change its state.
+func newDryRunServer(t *testing.T, responses map[string]interface{}) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected request %s %s in dry-run mode", r.Method, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		response, ok := responses[r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"Object Not Found","reason":"Not Found"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+}
+
+// newDryRunProvider creates a dry-run Provider for the given server, writing the
+// operations to output.
+func newDryRunProvider(server *httptest.Server, vhost string, output *bytes.Buffer) Provider {
+	return NewProvider(&RabbitMQConfig{
+		Address:      strings.TrimPrefix(server.URL, "http://"),
+		User:         "guest",
+		Password:     "guest",
+		VirtualHost:  vhost,
+		DryRun:       true,
+		DryRunOutput: output,
+	})
+}
+
+func TestDryRun_CreateExchange(t *testing.T) {
+	server := newDryRunServer(t, map[string]interface{}{
+		"/api/exchanges/%2F/existing": map[string]interface{}{
+			"name": "existing", "vhost": "/", "type": "direct", "durable": true, "auto_delete": false,
+		},
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		exchange Exchange
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "new exchange",
+			exchange: Exchange{Name: "my exchange", Type: Topic, Durable: true},
+			want:     `HTTP PUT /api/exchanges/%2F/my%20exchange {"type":"topic","durable":true}` + "\n",
+		},
+		{
+			name:     "equivalent exchange",
+			exchange: Exchange{Name: "existing", Type: Direct, Durable: true},
+			want:     `HTTP PUT /api/exchanges/%2F/existing {"type":"direct","durable":true}` + "\n",
+		},
+		{
+			name:     "exchange with different properties",
+			exchange: Exchange{Name: "existing", Type: Fanout, Durable: true},
+			wantErr:  ErrAlreadyExistsWithDifferentProperties,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			err := newDryRunProvider(server, "", &output).CreateExchange(context.Background(), test.exchange)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+			if output.String() != test.want {
+				t.Errorf("expected output %q, got %q", test.want, output.String())
+			}
+		})
+	}
+}
+
+func TestDryRun_CreateQueue(t *testing.T) {
+	server := newDryRunServer(t, map[string]interface{}{
+		"/api/queues/team%2Fa/classic": map[string]interface{}{
+			"name": "classic", "vhost": "team/a", "durable": false, "auto_delete": false, "arguments": map[string]interface{}{},
+		},
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		queue   Queue
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "new queue",
+			queue: Queue{Name: "orders/eu", Type: Quorum, Durable: true},
+			want:  `HTTP PUT /api/queues/team%2Fa/orders%2Feu {"type":"quorum","durable":true}` + "\n",
+		},
+		{
+			// Classic queues may lack the x-queue-type argument.
+			name:  "existing classic queue",
+			queue: Queue{Name: "classic", Type: Classic},
+			want:  `HTTP PUT /api/queues/team%2Fa/classic {"type":"classic","durable":false}` + "\n",
+		},
+		{
+			name:    "queue with different type",
+			queue:   Queue{Name: "classic", Type: Quorum},
+			wantErr: ErrAlreadyExistsWithDifferentProperties,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			_, err := newDryRunProvider(server, "team/a", &output).CreateQueue(context.Background(), test.queue)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+			if output.String() != test.want {
+				t.Errorf("expected output %q, got %q", test.want, output.String())
+			}
+		})
+	}
+}
+
+func TestDryRun_CreateBinding(t *testing.T) {
+	server := newDryRunServer(t, map[string]interface{}{
+		"/api/exchanges/%2F/source": map[string]interface{}{"name": "source", "type": "topic"},
+		"/api/queues/%2F/target":    map[string]interface{}{"name": "target"},
+	})
+	defer server.Close()
+
+	var output bytes.Buffer
+
+	err := newDryRunProvider(server, "", &output).CreateBinding(context.Background(), Binding{
+		Type:       ToQueue,
+		From:       Exchange{Name: "source"},
+		TargetName: "target",
+		Key:        "orders.*",
+	})
+	if err != nil {
+		t.Fatalf("creating binding: %v", err)
+	}
+
+	want := `HTTP POST /api/bindings/%2F/e/source/q/target ` +
+		`{"source":"source","vhost":"/","destination":"target","destination_type":"queue","routing_key":"orders.*","arguments":{},"properties_key":""}` + "\n"
+
+	if output.String() != want {
+		t.Errorf("expected output %q, got %q", want, output.String())
+	}
+
+	output.Reset()
+
+	err = newDryRunProvider(server, "", &output).CreateBinding(context.Background(), Binding{
+		Type:       ToQueue,
+		From:       Exchange{Name: "source"},
+		TargetName: "missing",
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected error %v, got %v", ErrNotFound, err)
+	}
+	if output.Len() != 0 {
+		t.Errorf("expected no output for a missing target, got %q", output.String())
+	}
+}
+
+func TestDryRun_Delete(t *testing.T) {
+	server := newDryRunServer(t, map[string]interface{}{
+		"/api/exchanges/%2F/my-exchange": map[string]interface{}{"name": "my-exchange", "type": "direct"},
+		"/api/queues/%2F/my%20queue":     map[string]interface{}{"name": "my queue"},
+	})
+	defer server.Close()
+
+	var output bytes.Buffer
+
+	provider := newDryRunProvider(server, "", &output)
+
+	if err := provider.DeleteExchange(context.Background(), Exchange{Name: "my-exchange"}); err != nil {
+		t.Fatalf("deleting exchange: %v", err)
+	}
+	if err := provider.DeleteQueue(context.Background(), Queue{Name: "my queue"}); err != nil {
+		t.Fatalf("deleting queue: %v", err)
+	}
+
+	want := "HTTP DELETE /api/exchanges/%2F/my-exchange\nHTTP DELETE /api/queues/%2F/my%20queue\n"
+
+	if output.String() != want {
+		t.Errorf("expected output %q, got %q", want, output.String())
+	}
+
+	if err := provider.DeleteQueue(context.Background(), Queue{Name: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected error %v, got %v", ErrNotFound, err)
+	}
+}
+
+func TestDryRun_PublishMessages(t *testing.T) {
+	server := newDryRunServer(t, map[string]interface{}{
+		"/api/exchanges/%2F/my-exchange": map[string]interface{}{"name": "my-exchange", "type": "direct"},
+	})
+	defer server.Close()
+
+	var output bytes.Buffer
+
+	messages := []Message{
+		{Target: Exchange{Name: "my-exchange"}, RoutingKey: "key", Body: []byte("hello")},
+		// The default exchange always exists and isn't requested.
+		{RoutingKey: "my-queue", Body: []byte("{}"), Properties: MessageProperties{ContentType: "application/json"}},
+	}
+
+	count, err := newDryRunProvider(server, "", &output).PublishMessages(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("publishing messages: %v", err)
+	}
+	if count != len(messages) {
+		t.Errorf("expected %d messages, got %d", len(messages), count)
+	}
+
+	want := `AMQP basic.publish exchange="my-exchange" routing_key="key" mandatory=false content_type="" body=5 bytes` + "\n" +
+		`AMQP basic.publish exchange="" routing_key="my-queue" mandatory=false content_type="application/json" body=2 bytes` + "\n"
+
+	if output.String() != want {
+		t.Errorf("expected output %q, got %q", want, output.String())
+	}
+
+	err = newDryRunProvider(server, "", &output).PublishMessage(context.Background(), Message{Target: Exchange{Name: "missing"}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected error %v, got %v", ErrNotFound, err)
+	}
+}
+
+func TestDryRun_Unsupported(t *testing.T) {
+	server := newDryRunServer(t, nil)
+	defer server.Close()
+
+	provider := newDryRunProvider(server, "", &bytes.Buffer{})
+
+	if _, err := provider.GetMessages(context.Background(), Queue{Name: "q"}, 1, false); !errors.Is(err, ErrDryRun) {
+		t.Errorf("expected error %v, got %v", ErrDryRun, err)
+	}
+	if err := provider.DeleteShovel(context.Background(), Shovel{Name: "shovel"}); !errors.Is(err, ErrDryRun) {
+		t.Errorf("expected error %v, got %v", ErrDryRun, err)
+	}
+}
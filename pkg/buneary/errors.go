@@ -0,0 +1,166 @@
+package buneary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/streadway/amqp"
+)
+
+var (
+	// ErrNotFound indicates that a resource like an exchange or a queue doesn't exist.
+	ErrNotFound = errors.New("resource not found")
+
+	// ErrAlreadyExistsWithDifferentProperties indicates that a resource already exists
+	// but has been declared with other properties, e.g. a durable queue that is being
+	// re-declared as non-durable.
+	ErrAlreadyExistsWithDifferentProperties = errors.New("resource already exists with different properties")
+
+	// ErrAccessRefused indicates that the user is authenticated but lacks permissions
+	// for the requested operation.
+	ErrAccessRefused = errors.New("access refused")
+
+	// ErrUnauthorized indicates that the user couldn't be authenticated, usually due
+	// to invalid credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrResourceLocked indicates that a resource is in exclusive use by another
+	// connection, e.g. an exclusive queue.
+	ErrResourceLocked = errors.New("resource locked")
+)
+
+// ServerError is an error reported by the RabbitMQ server, either as a status code of
+// the HTTP API or as a close code of an AMQP connection or channel.
+//
+// Known errors unwrap to one of the sentinel errors such as ErrNotFound, so they can
+// be checked using errors.Is. Use errors.As to access the details.
+type ServerError struct {
+
+	// StatusCode is the HTTP status code. It is 0 for AMQP errors.
+	StatusCode int
+
+	// Code is the AMQP reply code. It is 0 for HTTP errors.
+	Code int
+
+	// Reason is the reason reported by the server.
+	Reason string
+
+	kind error
+}
+
+// Error implements the error interface.
+func (e *ServerError) Error() string {
+	reason := e.Reason
+
+	if reason == "" {
+		switch {
+		case e.StatusCode != 0:
+			reason = fmt.Sprintf("RabbitMQ server returned status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+		default:
+			reason = fmt.Sprintf("RabbitMQ server returned code %d", e.Code)
+		}
+	}
+
+	if e.kind == nil {
+		return reason
+	}
+
+	return fmt.Sprintf("%s: %s", e.kind, reason)
+}
+
+// Unwrap returns the sentinel error corresponding to the server error, or nil if the
+// server error is unknown.
+func (e *ServerError) Unwrap() error {
+	return e.kind
+}
+
+// newHTTPError creates a ServerError from an HTTP response with a 4xx status code. The
+// response body is expected to contain the error and its reason as JSON.
+func newHTTPError(response *http.Response) *ServerError {
+	// responseBody represents an error response body of the RabbitMQ HTTP API.
+	type responseBody struct {
+		Error  string `json:"error"`
+		Reason string `json:"reason"`
+	}
+
+	body := responseBody{}
+	_ = json.NewDecoder(io.LimitReader(response.Body, 64*1024)).Decode(&body)
+
+	serverErr := &ServerError{
+		StatusCode: response.StatusCode,
+		Reason:     body.Reason,
+	}
+
+	switch response.StatusCode {
+	case http.StatusUnauthorized:
+		serverErr.kind = ErrUnauthorized
+	case http.StatusForbidden:
+		serverErr.kind = ErrAccessRefused
+	case http.StatusNotFound:
+		serverErr.kind = ErrNotFound
+	case http.StatusBadRequest:
+		// The HTTP API reports failed AMQP operations as 400 Bad Request, so they
+		// can only be told apart by their reason.
+		switch reason := strings.ToLower(body.Reason); {
+		case strings.Contains(reason, "inequivalent arg"), strings.Contains(reason, "precondition_failed"):
+			serverErr.kind = ErrAlreadyExistsWithDifferentProperties
+		case strings.Contains(reason, "resource_locked"):
+			serverErr.kind = ErrResourceLocked
+		}
+	}
+
+	return serverErr
+}
+
+// newAMQPError creates a ServerError from an AMQP error. Errors that haven't been
+// reported by the server, such as amqp.ErrClosed, are returned as they are.
+func newAMQPError(err error) error {
+	var amqpErr *amqp.Error
+	if !errors.As(err, &amqpErr) {
+		return err
+	}
+
+	serverErr := &ServerError{
+		Code:   amqpErr.Code,
+		Reason: amqpErr.Reason,
+	}
+
+	switch {
+	case amqpErr == amqp.ErrCredentials:
+		serverErr.kind = ErrUnauthorized
+	case !amqpErr.Server:
+		return err
+	case amqpErr.Code == amqp.AccessRefused:
+		serverErr.kind = ErrAccessRefused
+	case amqpErr.Code == amqp.NotFound:
+		serverErr.kind = ErrNotFound
+	case amqpErr.Code == amqp.ResourceLocked:
+		serverErr.kind = ErrResourceLocked
+	case amqpErr.Code == amqp.PreconditionFailed:
+		serverErr.kind = ErrAlreadyExistsWithDifferentProperties
+	}
+
+	return serverErr
+}
+
+// httpError returns the ServerError contained in an error returned by an HTTP client.
+// The HTTP client wraps all errors returned by the transport into an url.Error whose
+// message includes the request URL, which isn't helpful to the user.
+func httpError(err error) error {
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr
+	}
+	return err
+}
+
+// drain reads and closes the response body so that the connection can be re-used.
+func drain(body io.ReadCloser) {
+	_, _ = io.Copy(ioutil.Discard, body)
+	_ = body.Close()
+}
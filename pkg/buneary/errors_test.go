@@ -0,0 +1,189 @@
+package buneary
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/streadway/amqp"
+)
+
+func TestNewHTTPError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       error
+		wantReason string
+	}{
+		{
+			name:       "unauthorized",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"error":"not_authorised","reason":"Login failed"}`,
+			want:       ErrUnauthorized,
+			wantReason: "Login failed",
+		},
+		{
+			name:       "forbidden",
+			statusCode: http.StatusForbidden,
+			body:       `{"error":"not_authorised","reason":"Not management user"}`,
+			want:       ErrAccessRefused,
+			wantReason: "Not management user",
+		},
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			body:       `{"error":"Object Not Found","reason":"Not Found"}`,
+			want:       ErrNotFound,
+			wantReason: "Not Found",
+		},
+		{
+			name:       "inequivalent arguments",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":"bad_request","reason":"inequivalent arg 'durable' for queue 'q' in vhost '/'"}`,
+			want:       ErrAlreadyExistsWithDifferentProperties,
+			wantReason: "inequivalent arg 'durable' for queue 'q' in vhost '/'",
+		},
+		{
+			name:       "precondition failed",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":"bad_request","reason":"PRECONDITION_FAILED - invalid arg 'x-max-length'"}`,
+			want:       ErrAlreadyExistsWithDifferentProperties,
+			wantReason: "PRECONDITION_FAILED - invalid arg 'x-max-length'",
+		},
+		{
+			name:       "resource locked",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":"bad_request","reason":"RESOURCE_LOCKED - cannot obtain exclusive access to locked queue 'q'"}`,
+			want:       ErrResourceLocked,
+			wantReason: "RESOURCE_LOCKED - cannot obtain exclusive access to locked queue 'q'",
+		},
+		{
+			name:       "unknown bad request",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":"bad_request","reason":"something else"}`,
+			wantReason: "something else",
+		},
+		{
+			name:       "body without JSON",
+			statusCode: http.StatusNotFound,
+			body:       "Not Found",
+			want:       ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := &http.Response{
+				StatusCode: test.statusCode,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+
+			err := newHTTPError(response)
+
+			if test.want != nil && !errors.Is(err, test.want) {
+				t.Errorf("expected %v to be %v", err, test.want)
+			}
+			if test.want == nil && err.Unwrap() != nil {
+				t.Errorf("expected %v not to wrap a sentinel error, got %v", err, err.Unwrap())
+			}
+			if err.StatusCode != test.statusCode || err.Reason != test.wantReason {
+				t.Errorf("expected status %d and reason %q, got %d and %q", test.statusCode, test.wantReason,
+					err.StatusCode, err.Reason)
+			}
+		})
+	}
+}
+
+func TestNewAMQPError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "access refused",
+			err:  &amqp.Error{Code: amqp.AccessRefused, Reason: "ACCESS_REFUSED", Server: true},
+			want: ErrAccessRefused,
+		},
+		{
+			name: "not found",
+			err:  &amqp.Error{Code: amqp.NotFound, Reason: "NOT_FOUND - no queue 'q'", Server: true},
+			want: ErrNotFound,
+		},
+		{
+			name: "resource locked",
+			err:  &amqp.Error{Code: amqp.ResourceLocked, Reason: "RESOURCE_LOCKED", Server: true},
+			want: ErrResourceLocked,
+		},
+		{
+			name: "precondition failed",
+			err:  &amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED", Server: true},
+			want: ErrAlreadyExistsWithDifferentProperties,
+		},
+		{
+			name: "credentials",
+			err:  amqp.ErrCredentials,
+			want: ErrUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := newAMQPError(test.err)
+
+			if !errors.Is(err, test.want) {
+				t.Errorf("expected %v to be %v", err, test.want)
+			}
+
+			var serverErr *ServerError
+			if !errors.As(err, &serverErr) {
+				t.Fatalf("expected %v to be a ServerError", err)
+			}
+
+			amqpErr := test.err.(*amqp.Error)
+			if serverErr.Code != amqpErr.Code || serverErr.Reason != amqpErr.Reason {
+				t.Errorf("expected code %d and reason %q, got %d and %q", amqpErr.Code, amqpErr.Reason,
+					serverErr.Code, serverErr.Reason)
+			}
+		})
+	}
+}
+
+func TestNewAMQPError_NotFromServer(t *testing.T) {
+	plain := errors.New("connection reset")
+
+	for _, err := range []error{amqp.ErrClosed, plain} {
+		if got := newAMQPError(err); got != err {
+			t.Errorf("expected %v to be returned as it is, got %v", err, got)
+		}
+	}
+}
+
+func TestServerError_Error(t *testing.T) {
+	tests := []struct {
+		err  *ServerError
+		want string
+	}{
+		{
+			err:  &ServerError{StatusCode: http.StatusNotFound, Reason: "Not Found", kind: ErrNotFound},
+			want: "resource not found: Not Found",
+		},
+		{
+			err:  &ServerError{StatusCode: http.StatusForbidden, kind: ErrAccessRefused},
+			want: "access refused: RabbitMQ server returned status 403 Forbidden",
+		},
+		{
+			err:  &ServerError{Code: amqp.InternalError},
+			want: "RabbitMQ server returned code 541",
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.want {
+			t.Errorf("expected %q, got %q", test.want, got)
+		}
+	}
+}
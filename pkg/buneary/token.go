@@ -0,0 +1,156 @@
+package buneary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryDelta is the time before its expiry at which a token gets refreshed, so
+// that it doesn't expire while a request is in flight.
+const tokenExpiryDelta = 30 * time.Second
+
+// TokenSource provides OAuth2 access tokens for servers using the OAuth2 backend of
+// RabbitMQ, rabbitmq_auth_backend_oauth2. Token is called for each HTTP request and
+// for each AMQP connection, so implementations should cache the token and refresh it
+// before it expires.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token. Since the token
+// can't be refreshed, it is only suitable for short-lived processes.
+type StaticToken string
+
+// Token implements TokenSource.Token.
+func (t StaticToken) Token(_ context.Context) (string, error) {
+	return string(t), nil
+}
+
+// ClientCredentials is a TokenSource that obtains tokens from an OAuth2 token endpoint
+// using the client credentials grant. The token is cached and refreshed shortly
+// before it expires.
+//
+// A ClientCredentials must not be copied after first use. It is safe for concurrent
+// use, so multiple Providers can share it.
+type ClientCredentials struct {
+
+	// TokenURL is the URL of the token endpoint, e.g. the /token endpoint of the
+	// OAuth2 server.
+	TokenURL string
+
+	// ClientID is the ID of the OAuth2 client.
+	ClientID string
+
+	// ClientSecret is the secret of the OAuth2 client.
+	ClientSecret string
+
+	// Scopes are the requested scopes, e.g. rabbitmq.read:*/*. If empty, the token
+	// endpoint's default scopes are granted.
+	Scopes []string
+
+	// HTTPClient is the HTTP client for requesting tokens. If nil, the default HTTP
+	// client is used.
+	HTTPClient *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// tokenResponse is the successful response of an OAuth2 token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// tokenErrorResponse is the error response of an OAuth2 token endpoint.
+type tokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token implements TokenSource.Token. A new token is requested if there is no cached
+// token or if it is about to expire. Tokens without an expiry are cached forever.
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && (c.expiry.IsZero() || time.Now().Before(c.expiry.Add(-tokenExpiryDelta))) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("creating token request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("requesting token: %w", err)
+	}
+
+	defer drain(response.Body)
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading token response: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var tokenError tokenErrorResponse
+		_ = json.Unmarshal(body, &tokenError)
+
+		reason := tokenError.ErrorDescription
+		if reason == "" {
+			reason = tokenError.Error
+		}
+		if reason == "" {
+			reason = http.StatusText(response.StatusCode)
+		}
+
+		return "", fmt.Errorf("requesting token: token endpoint returned status %d: %s", response.StatusCode, reason)
+	}
+
+	var token tokenResponse
+
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return "", errors.New("requesting token: token endpoint returned no access token")
+	}
+
+	c.token = token.AccessToken
+	c.expiry = time.Time{}
+
+	if token.ExpiresIn > 0 {
+		c.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return c.token, nil
+}